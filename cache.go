@@ -0,0 +1,79 @@
+package feng
+
+import "sync"
+
+// CachedGetter memoizes parsed environment values for hot paths.
+//
+// Values are read and parsed on first use and served from memory afterwards.
+// Only successful parses are cached. Call Invalidate or InvalidateAll after the
+// environment changes, e.g. after Reload. The zero value is ready to use and a
+// CachedGetter is safe for concurrent use.
+type CachedGetter struct {
+	// each parsed type has its own map keyed by variable name, so the same
+	// variable can be read as several types.
+	ints     sync.Map
+	int64s   sync.Map
+	float64s sync.Map
+	bools    sync.Map
+}
+
+// NewCachedGetter returns an empty CachedGetter.
+func NewCachedGetter() *CachedGetter {
+	return &CachedGetter{}
+}
+
+// Int returns the cached result of GetenvInt for key.
+func (c *CachedGetter) Int(key string) (int, error) {
+	return cachedGet(&c.ints, key, GetenvInt)
+}
+
+// Int64 returns the cached result of GetenvInt64 for key.
+func (c *CachedGetter) Int64(key string) (int64, error) {
+	return cachedGet(&c.int64s, key, GetenvInt64)
+}
+
+// Float64 returns the cached result of GetenvFloat64 for key.
+func (c *CachedGetter) Float64(key string) (float64, error) {
+	return cachedGet(&c.float64s, key, GetenvFloat64)
+}
+
+// Bool returns the cached result of GetenvBool for key.
+func (c *CachedGetter) Bool(key string) (bool, error) {
+	return cachedGet(&c.bools, key, GetenvBool)
+}
+
+// Invalidate drops every cached value for key.
+func (c *CachedGetter) Invalidate(key string) {
+	for _, m := range c.maps() {
+		m.Delete(key)
+	}
+}
+
+// InvalidateAll drops every cached value.
+func (c *CachedGetter) InvalidateAll() {
+	for _, m := range c.maps() {
+		m.Range(func(k, _ interface{}) bool {
+			m.Delete(k)
+			return true
+		})
+	}
+}
+
+// maps returns the per-type caches.
+func (c *CachedGetter) maps() []*sync.Map {
+	return []*sync.Map{&c.ints, &c.int64s, &c.float64s, &c.bools}
+}
+
+// cachedGet returns the value cached in m for key, calling get and storing
+// its result on a miss.
+func cachedGet[T any](m *sync.Map, key string, get func(string) (T, error)) (T, error) {
+	if v, ok := m.Load(key); ok {
+		return v.(T), nil
+	}
+	v, err := get(key)
+	if err != nil {
+		return v, err
+	}
+	m.Store(key, v)
+	return v, nil
+}
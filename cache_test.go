@@ -0,0 +1,93 @@
+package feng_test
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestCachedGetter(t *testing.T) {
+	t.Setenv("FENG_CACHED_INT", "42")
+	c := feng.NewCachedGetter()
+
+	// Test case 1: Values are served from the cache after the first read
+	t.Run("Cache hit", func(t *testing.T) {
+		if v, err := c.Int("FENG_CACHED_INT"); err != nil || v != 42 {
+			t.Fatalf("Expected 42, got %d (%v)", v, err)
+		}
+		os.Setenv("FENG_CACHED_INT", "7")
+		if v, _ := c.Int("FENG_CACHED_INT"); v != 42 {
+			t.Errorf("Expected cached 42, but got %d", v)
+		}
+	})
+
+	// Test case 2: Invalidate forces a fresh read
+	t.Run("Invalidate", func(t *testing.T) {
+		c.Invalidate("FENG_CACHED_INT")
+		if v, _ := c.Int("FENG_CACHED_INT"); v != 7 {
+			t.Errorf("Expected 7 after invalidation, but got %d", v)
+		}
+		os.Setenv("FENG_CACHED_INT", "8")
+		c.InvalidateAll()
+		if v, _ := c.Int("FENG_CACHED_INT"); v != 8 {
+			t.Errorf("Expected 8 after InvalidateAll, but got %d", v)
+		}
+	})
+
+	// Test case 3: Parse errors are not cached
+	t.Run("Errors are not cached", func(t *testing.T) {
+		t.Setenv("FENG_CACHED_BAD", "x")
+		if _, err := c.Int("FENG_CACHED_BAD"); err == nil {
+			t.Fatal("Expected a parse error")
+		}
+		os.Setenv("FENG_CACHED_BAD", "3")
+		if v, err := c.Int("FENG_CACHED_BAD"); err != nil || v != 3 {
+			t.Errorf("Expected 3, got %d (%v)", v, err)
+		}
+	})
+
+	// Test case 4: Concurrent reads and invalidations
+	t.Run("Concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if _, err := c.Int("FENG_CACHED_INT"); err != nil {
+						t.Errorf("Int returned an error: %v", err)
+						return
+					}
+					if j%10 == 0 {
+						c.InvalidateAll()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkGetenvInt(b *testing.B) {
+	os.Setenv("FENG_BENCH_INT", strconv.Itoa(12345))
+	defer os.Unsetenv("FENG_BENCH_INT")
+	for i := 0; i < b.N; i++ {
+		if _, err := feng.GetenvInt("FENG_BENCH_INT"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCachedGetterInt(b *testing.B) {
+	os.Setenv("FENG_BENCH_INT", strconv.Itoa(12345))
+	defer os.Unsetenv("FENG_BENCH_INT")
+	c := feng.NewCachedGetter()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Int("FENG_BENCH_INT"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package feng
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cronField describes the allowed range and names of one cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names[i] stands for min+i
+}
+
+var (
+	cronSeconds = cronField{name: "second", min: 0, max: 59}
+	cronFields  = []cronField{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+		{name: "day of week", min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+	}
+	cronMacros = map[string]bool{
+		"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+		"@daily": true, "@midnight": true, "@hourly": true,
+	}
+)
+
+// GetenvCron validates the cron expression stored in the environment variable named
+// by key and returns it.
+//
+// The standard 5-field form (minute hour day-of-month month day-of-week) and a 6-field
+// form with a leading seconds field are accepted, as are the @yearly, @monthly,
+// @weekly, @daily and @hourly macros. Each field may be `*`, a number, a range `a-b`,
+// a step `*/n` or `a-b/n`, or a comma separated list of these; months and weekdays
+// may also be given by their three-letter names. The error describes the first
+// invalid field.
+func GetenvCron(key string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s not set", key)
+	}
+	if cronMacros[strings.ToLower(value)] {
+		return value, nil
+	}
+
+	fields := strings.Fields(value)
+	specs := cronFields
+	switch len(fields) {
+	case 5:
+	case 6:
+		specs = append([]cronField{cronSeconds}, cronFields...)
+	default:
+		return "", fmt.Errorf("invalid cron expression in environment variable %s: expected 5 or 6 fields, got %d", key, len(fields))
+	}
+
+	for i, f := range fields {
+		if err := validateCronField(f, specs[i]); err != nil {
+			return "", fmt.Errorf("invalid cron expression in environment variable %s: %s field: %w", key, specs[i].name, err)
+		}
+	}
+	return value, nil
+}
+
+// validateCronField checks one comma separated cron field against spec.
+func validateCronField(field string, spec cronField) error {
+	for _, part := range strings.Split(field, ",") {
+		rng, step, hasStep := strings.Cut(part, "/")
+		if hasStep {
+			n, err := strconv.Atoi(step)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step %q", step)
+			}
+		}
+		if rng == "*" {
+			continue
+		}
+
+		lo, hi, isRange := strings.Cut(rng, "-")
+		start, err := cronValue(lo, spec)
+		if err != nil {
+			return err
+		}
+		if !isRange {
+			continue
+		}
+		end, err := cronValue(hi, spec)
+		if err != nil {
+			return err
+		}
+		if start > end {
+			return fmt.Errorf("invalid range %q", rng)
+		}
+	}
+	return nil
+}
+
+// cronValue parses a single number or name and checks it is within spec's range.
+func cronValue(s string, spec cronField) (int, error) {
+	for i, name := range spec.names {
+		if strings.EqualFold(s, name) {
+			return spec.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", n, spec.min, spec.max)
+	}
+	return n, nil
+}
@@ -0,0 +1,43 @@
+package feng_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestGetenvCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"five fields", "0 2 * * *", ""},
+		{"six fields with seconds", "30 0 2 * * MON-FRI", ""},
+		{"lists and steps", "*/15 9-17 1,15 JAN-JUN 1-5/2", ""},
+		{"macro", "@daily", ""},
+		{"wrong field count", "0 2 * *", "expected 5 or 6 fields"},
+		{"out of range field", "0 24 * * *", "hour field: value 24 out of range"},
+		{"bad step", "*/0 * * * *", "invalid step"},
+		{"unset", "", "not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_BACKUP_SCHEDULE", tt.value)
+			got, err := feng.GetenvCron("FENG_BACKUP_SCHEDULE")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("GetenvCron returned an error: %v", err)
+				}
+				if got != tt.value {
+					t.Errorf("Expected %q, but got %q", tt.value, got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
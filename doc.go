@@ -0,0 +1,37 @@
+// Package feng reads application configuration from environment variables and
+// .env files, following the twelve-factor app methodology.
+//
+// # Quoting
+//
+// In .env files a value may be written unquoted, in double quotes or in single
+// quotes:
+//
+//	A=plain value      # unquoted: trimmed, ends at the first #
+//	B="it's fine"      # double quotes may contain ' and #
+//	C='say "hi"'       # single quotes may contain " and #
+//
+// A value is quoted when it starts and ends with the same quote character; the
+// quotes are removed and the text between them is kept verbatim, including
+// surrounding spaces. There are no escape sequences, so a backslash is kept as is.
+// A comment may follow the closing quote. A value that starts with a quote
+// character which never appears again on the line, such as "unterminated, is
+// reported as an error instead of being read as plain text.
+//
+// Values spanning several lines are written as a heredoc: a `KEY<<EOF` line
+// followed by the value's lines and a closing line consisting only of EOF.
+//
+// # Numeric values
+//
+// The numeric getters such as GetenvInt, GetenvUint32 and GetenvFloat64 trim
+// surrounding whitespace from the value before parsing, so " 42 " reads as 42.
+// Values copied from files or shell scripts often carry stray spaces, and these
+// are rarely intentional. Callers that want such values rejected can use the
+// Strict variants, e.g. GetenvIntStrict, which fail when the value has leading
+// or trailing whitespace.
+//
+// The integer getters infer the base from the value's prefix, following
+// strconv.ParseInt with base 0: "0x" or "0X" selects hexadecimal, "0o" or "0O"
+// octal and "0b" or "0B" binary, while other values are decimal. As in Go source,
+// a plain leading zero also selects octal, so "0755" reads as 493 and "09" is an
+// error. Underscores between digits, as in "1_000", are permitted.
+package feng
@@ -1,20 +1,32 @@
 package feng
 
 import (
-	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"image/color"
+	"io/fs"
+	"math"
+	"mime"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	lineRegx = regexp.MustCompile(`\A\s*(?:export\s+)?([\w\.]+)(?:\s*=\s*|:\s+?)('(?:\'|[^'])*'|"(?:\"|[^"])*"|[^#\n]+)?\s*(?:\s*\#.*)?\z`)
-	// TODO: Handle variable environment variables
-	// variableRegx = regexp.MustCompile(`(\\)?(\$)(\{?([A-Z0-9_]+)?\}?)`)
-	// unescapeRgx  = regexp.MustCompile(`\\([^$])`)
 )
 
 // GetenvInt8 retrieves the value of the specified environment variable as an int8.
@@ -24,12 +36,12 @@ var (
 // The function returns an int8 and an error. The int8 represents the value of the environment variable
 // converted to int8. The error is non-nil if there was an error retrieving or converting the value.
 func GetenvInt8(key string) (int8, error) {
-	value := os.Getenv(key)
+	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
 		return 0, fmt.Errorf("environment variable not found: %s", key)
 	}
 
-	intValue, err := strconv.ParseInt(value, 10, 8)
+	intValue, err := strconv.ParseInt(value, 0, 8)
 	if err != nil {
 		return 0, fmt.Errorf("failed to convert environment variable to int8: %s", key)
 	}
@@ -46,11 +58,11 @@ func GetenvInt8(key string) (int8, error) {
 // - int16: The value of the environment variable as an int16.
 // - error: An error if the environment variable is not set or if it fails to be parsed as an int16.
 func GetenvInt16(key string) (int16, error) {
-	val := os.Getenv(key)
+	val := strings.TrimSpace(os.Getenv(key))
 	if val == "" {
 		return 0, fmt.Errorf("environment variable %s not set", key)
 	}
-	num, err := strconv.ParseInt(val, 10, 16)
+	num, err := strconv.ParseInt(val, 0, 16)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse environment variable %s as int16: %w", key, err)
 	}
@@ -66,11 +78,11 @@ func GetenvInt16(key string) (int16, error) {
 // - int64: The value of the environment variable as an int64.
 // - error: An error if the environment variable does not exist or if it cannot be parsed as an int64.
 func GetenvInt64(key string) (int64, error) {
-	value := os.Getenv(key)
+	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
 		return 0, nil
 	}
-	parsedValue, err := strconv.ParseInt(value, 10, 64)
+	parsedValue, err := strconv.ParseInt(value, 0, 64)
 	if err != nil {
 		return 0, err
 	}
@@ -89,7 +101,7 @@ func GetenvInt32(key string) (int32, error) {
 	if !ok {
 		return 0, nil
 	}
-	intValue, err := strconv.ParseInt(value, 10, 32)
+	intValue, err := strconv.ParseInt(strings.TrimSpace(value), 0, 32)
 	if err != nil {
 		return 0, err
 	}
@@ -102,11 +114,11 @@ func GetenvInt32(key string) (int32, error) {
 //
 // It returns a uint8 value, which is the converted value of the environment variable, and an error if the conversion fails.
 func GetenvUint8(key string) (uint8, error) {
-	value := os.Getenv(key)
+	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
 		return 0, errors.New("environment variable not set")
 	}
-	i, err := strconv.ParseUint(value, 10, 8)
+	i, err := strconv.ParseUint(value, 0, 8)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse environment variable: %w", err)
 	}
@@ -117,12 +129,12 @@ func GetenvUint8(key string) (uint8, error) {
 // parameter and returns it as a uint16. If the environment variable is not set
 // or if the value cannot be parsed as a uint16, it returns an error.
 func GetenvUint16(key string) (uint16, error) {
-	value := os.Getenv(key)
+	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
 		return 0, errors.New("environment variable not set")
 	}
 
-	i, err := strconv.ParseUint(value, 10, 16)
+	i, err := strconv.ParseUint(value, 0, 16)
 	if err != nil {
 		return 0, err
 	}
@@ -136,7 +148,7 @@ func GetenvFloat64(key string) (float64, error) {
 	if !ok {
 		return 0, fmt.Errorf("%s environment variable not set", key)
 	}
-	value, err := strconv.ParseFloat(valueStr, 64)
+	value, err := strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse %s environment variable as float64: %w", key, err)
 	}
@@ -152,7 +164,7 @@ func GetenvFloat64(key string) (float64, error) {
 // - float32: The value of the environment variable, converted to a float32.
 // - error: An error if the conversion fails or the environment variable does not exist.
 func GetenvFloat32(key string) (float32, error) {
-	valueStr := os.Getenv(key)
+	valueStr := strings.TrimSpace(os.Getenv(key))
 	value, err := strconv.ParseFloat(valueStr, 32)
 	if err != nil {
 		return 0, err
@@ -170,14 +182,14 @@ func GetenvFloat32(key string) (float32, error) {
 // - uint64: the value of the environment variable as an unsigned 64-bit integer.
 // - error: any error that occurred during the conversion or retrieval process.
 func GetenvUint64(key string) (uint64, error) {
-	return strconv.ParseUint(os.Getenv(key), 10, 64)
+	return strconv.ParseUint(strings.TrimSpace(os.Getenv(key)), 0, 64)
 }
 
 // GetenvUint32 returns the value of the environment variable as a uint32.
 // It returns an error if the environment variable value cannot be parsed or if it is not present.
 func GetenvUint32(key string) (uint32, error) {
-	valueStr := os.Getenv(key)
-	value, err := strconv.ParseUint(valueStr, 10, 32)
+	valueStr := strings.TrimSpace(os.Getenv(key))
+	value, err := strconv.ParseUint(valueStr, 0, 32)
 	if err != nil {
 		return 0, err
 	}
@@ -193,12 +205,12 @@ func GetenvUint32(key string) (uint32, error) {
 // - int: The integer value parsed from the environment variable.
 // - error: An error if the value cannot be parsed as an integer or if the environment variable does not exist.
 func GetenvInt(key string) (int, error) {
-	valueStr := os.Getenv(key)
-	value, err := strconv.Atoi(valueStr)
+	valueStr := strings.TrimSpace(os.Getenv(key))
+	value, err := strconv.ParseInt(valueStr, 0, strconv.IntSize)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse environment variable as integer: %w", err)
 	}
-	return value, nil
+	return int(value), nil
 }
 
 // GetenvBool retrieves the boolean value of the specified environment variable.
@@ -237,6 +249,24 @@ func GetEnvOrDefault(key, defaultValue string) string {
 	return value
 }
 
+// GetenvStringOr returns the value of the environment variable named by key, or def if
+// it is unset or empty.
+//
+// When trim is true, surrounding whitespace is removed from the result, whether it
+// comes from the environment or from def, and a value that is blank after trimming
+// falls back to def as well.
+func GetenvStringOr(key, def string, trim bool) string {
+	value := os.Getenv(key)
+	if trim {
+		value = strings.TrimSpace(value)
+		def = strings.TrimSpace(def)
+	}
+	if value == "" {
+		return def
+	}
+	return value
+}
+
 // SetenvMap sets environment variables based on the provided map.
 //
 // Takes in a map of string key-value pairs representing environment variables.
@@ -257,27 +287,67 @@ func SetenvMap(envMap map[string]string) error {
 // prefix. If the prefix is an empty string, it retrieves all environment
 // variables.
 func GetenvMap(prefix string) map[string]string {
-	// Get all environment variables
-	envs := os.Environ()
+	envMap, _ := parseEnviron(os.Environ(), prefix)
+	return envMap
+}
 
-	// Create a map to store the resulting key-value pairs
-	envMap := make(map[string]string)
+// GetenvMapStrict is like GetenvMap but returns an error listing any malformed
+// environment entries, i.e. entries without a "=", instead of skipping them silently.
+func GetenvMapStrict(prefix string) (map[string]string, error) {
+	envMap, malformed := parseEnviron(os.Environ(), prefix)
+	if len(malformed) > 0 {
+		return nil, fmt.Errorf("malformed environment entries: %q", malformed)
+	}
+	return envMap, nil
+}
+
+// parseEnviron builds a map from the "key=value" entries of envs whose keys start
+// with prefix. Entries without a "=" are skipped and returned in malformed.
+func parseEnviron(envs []string, prefix string) (envMap map[string]string, malformed []string) {
+	// Create a map to store the resulting key-value pairs, sized for the
+	// common case of reading every variable
+	hint := 0
+	if prefix == "" {
+		hint = len(envs)
+	}
+	envMap = make(map[string]string, hint)
 
 	// Iterate through each environment variable
-	for _, v := range envs {
-		// Split the variable into key-value pair
-		envLine := strings.Split(v, "=")
-		k := envLine[0]
-		v := envLine[1]
+	for _, env := range envs {
+		// Split the variable at the first "=", values may contain "=" themselves
+		i := strings.IndexByte(env, '=')
+		if i < 0 {
+			malformed = append(malformed, env)
+			continue
+		}
+		k := env[:i]
 
 		// Check if the key starts with the given prefix or prefix is empty
-		if strings.HasPrefix(k, prefix) || prefix == "" {
+		if strings.HasPrefix(k, prefix) {
 			// Add the key-value pair to the map
-			envMap[k] = v
+			envMap[k] = env[i+1:]
 		}
 	}
 
 	// Return the resulting map
+	return envMap, malformed
+}
+
+// GetenvMapExclude retrieves every environment variable whose key does not start
+// with any of excludePrefixes.
+//
+// It is the complement of GetenvMap and is useful for sanitizing the environment
+// before passing it to a subprocess, e.g. GetenvMapExclude("AWS_", "SECRET_").
+func GetenvMapExclude(excludePrefixes ...string) map[string]string {
+	envMap := GetenvMap("")
+	for k := range envMap {
+		for _, prefix := range excludePrefixes {
+			if strings.HasPrefix(k, prefix) {
+				delete(envMap, k)
+				break
+			}
+		}
+	}
 	return envMap
 }
 
@@ -295,25 +365,16 @@ func ReadEnvFile(filename string) (map[string]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer data.Close()
 
-	envMap := make(map[string]string)
+	lines, err := parseLines(data)
+	if err != nil {
+		return nil, err
+	}
 
-	scanner := bufio.NewScanner(data)
-	scanner.Split(bufio.ScanLines)
-	for scanner.Scan() {
-		l := strings.TrimSpace(scanner.Text())
-		// skip empty lines and comment line
-		if l == "" || l[0] == '#' {
-			continue
-		}
-		// trim export start
-		l = strings.TrimPrefix(l, "export ")
-		parts := lineRegx.FindStringSubmatch(l)
-		if len(parts) != 0 {
-			key := removeQuotes(strings.TrimSpace(parts[1]))
-			value := removeQuotes(strings.TrimSpace(parts[2]))
-			envMap[key] = value
-		}
+	envMap := make(map[string]string)
+	for _, l := range lines {
+		envMap[l.key] = l.value
 	}
 
 	return envMap, nil
@@ -322,41 +383,10 @@ func ReadEnvFile(filename string) (map[string]string, error) {
 // Load reads an environment file and sets the environment variables accordingly.
 //
 // It takes a variable number of filenames as parameters and returns an error if any operation fails.
+// If no filenames are provided, the default ".env" file is read. Load is shorthand for
+// LoadWith(WithFiles(filenames...)).
 func Load(filenames ...string) error {
-	// Create a map to store the environment variables
-	envMap := make(map[string]string)
-
-	// Iterate over each filename provided
-	for _, filename := range filenames {
-		// Read the environment file and get the temporary environment map
-		tempEnvMap, err := ReadEnvFile(filename)
-		if err != nil {
-			// Return an error if reading the environment file fails
-			return fmt.Errorf("failed to read env file: %w", err)
-		}
-		// Merge the temporary environment map with the main environment map
-		envMap = mergeMaps(envMap, tempEnvMap)
-	}
-
-	// If no filenames are provided, read the default ".env" file
-	if len(filenames) == 0 {
-		tempEnvMap, err := ReadEnvFile(".env")
-		if err != nil {
-			// Return an error if reading the environment file fails
-			return fmt.Errorf("failed to read env file: %w", err)
-		}
-		// Merge the temporary environment map with the main environment map
-		envMap = mergeMaps(envMap, tempEnvMap)
-	}
-
-	// Set the environment variables using the map
-	if err := SetenvMap(envMap); err != nil {
-		// Return an error if setting the environment variables fails
-		return fmt.Errorf("failed to set environment variables: %w", err)
-	}
-
-	// Return nil if there are no errors
-	return nil
+	return LoadWith(WithFiles(filenames...))
 }
 
 // mergeMaps merges multiple maps into a single map.
@@ -401,56 +431,1842 @@ func removeQuotes(s string) string {
 //
 // The function takes a prefix string and a filename string as parameters.
 // It retrieves a map of environment variables using the GetenvMap function.
-// If the map is empty, the function returns nil.
-// Otherwise, it creates a new file with the given filename and writes each
-// key-value pair from the map to the file in the format "key=value\n".
+// If the map is empty, the function returns nil without touching the file,
+// unless WithCreateEmpty(true) is given, in which case an empty file is created.
+// Otherwise, it writes the map to the file with WriteEnvFileMap, one sorted
+// "key=value" line per variable, quoting values where needed.
 // Finally, it returns nil if the file is successfully written, or an error
 // if any error occurs during the process.
-func WriteEnvFile(prefix string, filename string) error {
+func WriteEnvFile(prefix string, filename string, opts ...WriteOption) error {
 	// Retrieve the environment variable map
 	envMap := GetenvMap(prefix)
 
-	// Return nil if the map is empty
+	// Return nil if the map is empty, unless an empty file was asked for
 	if len(envMap) == 0 {
-		return nil
+		var o writeOptions
+		for _, opt := range opts {
+			opt(&o)
+		}
+		if !o.createEmpty {
+			return nil
+		}
+	}
+
+	return WriteEnvFileMap(filename, envMap, opts...)
+}
+
+// ClearEnvSetting clears environment settings for the given environment names.
+// It takes a variadic parameter of environment names and returns an error, if any.
+func ClearEnvSetting(envNames ...string) error {
+	// Iterate over each environment name
+	for _, name := range envNames {
+		// Unset the environment variable
+		if err := os.Unsetenv(name); err != nil {
+			return err
+		}
+	}
+	// Return nil if no error occurred
+	return nil
+}
+
+// GetenvPath retrieves a filesystem path from the environment variable named by key.
+//
+// A leading `~` is expanded to the current user's home directory and `$VAR` or `${VAR}`
+// references are resolved with os.ExpandEnv. The result is returned as a cleaned
+// absolute path. An error is returned if the variable is not set or the home
+// directory cannot be determined.
+func GetenvPath(key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s not set", key)
 	}
 
-	// Create a new file with the given filename
-	f, err := os.Create(filename)
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand ~ in %s: %w", key, err)
+		}
+		value = home + value[1:]
+	}
+
+	path, err := filepath.Abs(os.ExpandEnv(value))
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to resolve path in %s: %w", key, err)
 	}
-	defer f.Close()
+	return filepath.Clean(path), nil
+}
+
+// The default patterns of the redacting helpers. GetenvMapJSONRedacted predates
+// MarshalRedacted and keeps its narrower pattern, so that output from existing callers
+// does not change; MarshalRedacted also masks KEY.
+var (
+	envSensitiveKeyPattern     = regexp.MustCompile(`(?i)SECRET|PASSWORD|TOKEN`)
+	defaultSensitiveKeyPattern = regexp.MustCompile(`(?i)SECRET|PASSWORD|TOKEN|KEY`)
+)
+
+// redactedValue replaces the value of sensitive keys.
+const redactedValue = "****"
+
+// GetenvMapJSON returns the environment variables starting with prefix as a JSON object.
+//
+// Keys are emitted in sorted order so the output is stable between calls.
+func GetenvMapJSON(prefix string) ([]byte, error) {
+	return json.Marshal(GetenvMap(prefix))
+}
+
+// GetenvMapJSONRedacted behaves like GetenvMapJSON but masks the value of any key
+// containing SECRET, PASSWORD or TOKEN (case-insensitive) with "****".
+//
+// Unlike MarshalRedacted it does not mask keys containing KEY, so API_KEY is written
+// in plain text. Use GetenvMapJSONRedactedWith to choose the pattern.
+func GetenvMapJSONRedacted(prefix string) ([]byte, error) {
+	return GetenvMapJSONRedactedWith(prefix, envSensitiveKeyPattern)
+}
+
+// GetenvMapJSONRedactedWith behaves like GetenvMapJSONRedacted but uses sensitive to
+// decide which keys to mask.
+func GetenvMapJSONRedactedWith(prefix string, sensitive *regexp.Regexp) ([]byte, error) {
+	envMap := GetenvMap(prefix)
+	for k := range envMap {
+		if sensitive.MatchString(k) {
+			envMap[k] = redactedValue
+		}
+	}
+	return json.Marshal(envMap)
+}
+
+// FlattenJSON flattens a JSON object into env-style keys suitable for SetenvMap.
+//
+// Nested object keys are joined with sep and array elements are indexed from 0, so
+// with sep "_" the document {"db":{"hosts":["a","b"]}} yields the keys
+// "db_hosts_0" and "db_hosts_1". Every key is prefixed with prefix. Key case is kept
+// as written unless WithUpperKeys is given, in which case the keys taken from the
+// document are uppercased (the prefix is used as is) and keys that differ only in
+// case are an error. Strings are used as is, numbers keep their textual form,
+// booleans become "true" or "false" and null becomes an empty string. Empty objects
+// and arrays produce no keys. data must hold a JSON object. Two paths that flatten to
+// the same key, such as {"a_b":1} and {"a":{"b":2}} with sep "_", are an error.
+func FlattenJSON(data []byte, prefix, sep string, opts ...FlattenOption) (map[string]string, error) {
+	var o flattenOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON object: %w", err)
+	}
+
+	f := jsonFlattener{
+		prefix: prefix,
+		sep:    sep,
+		upper:  o.upper,
+		envMap: make(map[string]string),
+		origin: make(map[string]string),
+	}
+	for _, k := range sortedJSONKeys(doc) {
+		if err := f.add(k, k, doc[k]); err != nil {
+			return nil, err
+		}
+	}
+	return f.envMap, nil
+}
+
+// flattenOptions holds the settings applied by FlattenOption values.
+type flattenOptions struct {
+	upper bool
+}
+
+// FlattenOption configures FlattenJSON.
+type FlattenOption func(*flattenOptions)
+
+// WithUpperKeys uppercases the keys produced by FlattenJSON when enabled, so that
+// {"db":{"host":"x"}} yields DB_HOST rather than db_host.
+func WithUpperKeys(enabled bool) FlattenOption {
+	return func(o *flattenOptions) {
+		o.upper = enabled
+	}
+}
+
+// jsonFlattener collects the leaves of a JSON document for FlattenJSON. origin maps
+// each key produced so far to the JSON path it came from.
+type jsonFlattener struct {
+	prefix, sep string
+	upper       bool
+	envMap      map[string]string
+	origin      map[string]string
+}
+
+// add adds v and its descendants under key. path names v in the document, e.g.
+// db.hosts[0], for error messages.
+func (f *jsonFlattener) add(key, path string, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedJSONKeys(v) {
+			if err := f.add(key+f.sep+k, path+"."+k, v[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		for i, child := range v {
+			if err := f.add(key+f.sep+strconv.Itoa(i), path+"["+strconv.Itoa(i)+"]", child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if f.upper {
+		key = strings.ToUpper(key)
+	}
+	key = f.prefix + key
+	if other, dup := f.origin[key]; dup {
+		return fmt.Errorf("JSON paths %s and %s both flatten to %s", other, path, key)
+	}
+	f.origin[key] = path
+
+	switch v := v.(type) {
+	case nil:
+		f.envMap[key] = ""
+	case string:
+		f.envMap[key] = v
+	default:
+		f.envMap[key] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// sortedJSONKeys returns the keys of obj in sorted order, so that FlattenJSON reports
+// the same collision on every run.
+func sortedJSONKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetenvStringSlice splits the value of the environment variable named by key on sep.
+//
+// Each element is trimmed of surrounding whitespace. A separator preceded by a
+// backslash is kept as a literal character inside the element, so with sep ","
+// the value `Smith\, John,Doe` yields ["Smith, John", "Doe"]. A backslash not
+// followed by the separator is left untouched. An unset or empty variable
+// returns an empty slice.
+func GetenvStringSlice(key, sep string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return []string{}
+	}
+	parts := splitEscaped(value, sep)
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+// splitEscaped splits s on sep, treating `\` followed by sep as a literal separator.
+// Without escapes it behaves exactly like strings.Split.
+func splitEscaped(s, sep string) []string {
+	escaped := `\` + sep
+	if sep == "" || !strings.Contains(s, escaped) {
+		return strings.Split(s, sep)
+	}
+
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], escaped):
+			cur.WriteString(sep)
+			i += len(escaped)
+		case strings.HasPrefix(s[i:], sep):
+			parts = append(parts, cur.String())
+			cur.Reset()
+			i += len(sep)
+		default:
+			cur.WriteByte(s[i])
+			i++
+		}
+	}
+	return append(parts, cur.String())
+}
 
-	// Create a buffered writer for the file
-	w := bufio.NewWriter(f)
+// Reload re-reads an environment file and applies only the keys whose values differ
+// from the current environment.
+//
+// Keys that are new or changed are set with os.Setenv and returned in changed.
+// Variables present in the environment but absent from the file are left alone.
+func Reload(filename string) (changed map[string]string, err error) {
+	envMap, err := ReadEnvFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
 
-	// Write each key-value pair from the map to the file
+	changed = make(map[string]string)
 	for k, v := range envMap {
-		_, err := w.WriteString(fmt.Sprintf("%s=%s\n", k, v))
+		if current, ok := os.LookupEnv(k); ok && current == v {
+			continue
+		}
+		if err := os.Setenv(k, v); err != nil {
+			return changed, fmt.Errorf("failed to set environment variable %s: %w", k, err)
+		}
+		changed[k] = v
+	}
+	return changed, nil
+}
+
+// GetenvSince parses the environment variable named by key as an RFC3339 timestamp
+// and returns the time elapsed since then.
+//
+// A timestamp in the future yields a negative duration. An error is returned if the
+// variable is not set or is not a valid RFC3339 timestamp.
+func GetenvSince(key string) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as RFC3339 time: %w", key, err)
+	}
+	return time.Since(t), nil
+}
+
+// GetenvMapTyped retrieves the environment variables starting with prefix and converts
+// each value with parse.
+//
+// The error names the first key, in sorted order, whose value could not be parsed.
+func GetenvMapTyped[T any](prefix string, parse func(string) (T, error)) (map[string]T, error) {
+	envMap := GetenvMap(prefix)
+	result := make(map[string]T, len(envMap))
+	for _, k := range sortedKeys(envMap) {
+		v, err := parse(envMap[k])
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to parse environment variable %s: %w", k, err)
 		}
+		result[k] = v
 	}
+	return result, nil
+}
 
-	// Flush the buffer and check for any error
-	if err := w.Flush(); err != nil {
-		return err
+// GetenvMapInt retrieves the environment variables starting with prefix and parses
+// each value as an int. The error names the first key, in sorted order, whose value
+// could not be parsed.
+func GetenvMapInt(prefix string) (map[string]int, error) {
+	return GetenvMapTyped(prefix, strconv.Atoi)
+}
+
+// GetenvMapBool retrieves the environment variables starting with prefix and parses
+// each value with strconv.ParseBool.
+func GetenvMapBool(prefix string) (map[string]bool, error) {
+	return GetenvMapTyped(prefix, strconv.ParseBool)
+}
+
+// GetenvMapFloat64 retrieves the environment variables starting with prefix and parses
+// each value as a float64.
+func GetenvMapFloat64(prefix string) (map[string]float64, error) {
+	return GetenvMapTyped(prefix, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetenvCSVRecords splits the value of the environment variable named by key into
+// records on recordSep and each record into fields on fieldSep.
+//
+// Fields are trimmed of surrounding whitespace and empty records, such as the one
+// produced by a trailing record separator, are skipped. An unset or empty variable
+// returns an empty slice.
+func GetenvCSVRecords(key, recordSep, fieldSep string) ([][]string, error) {
+	if recordSep == "" || fieldSep == "" {
+		return nil, errors.New("record and field separators must not be empty")
 	}
 
-	return nil
+	records := [][]string{}
+	for _, record := range strings.Split(os.Getenv(key), recordSep) {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		fields := strings.Split(record, fieldSep)
+		for i, f := range fields {
+			fields[i] = strings.TrimSpace(f)
+		}
+		records = append(records, fields)
+	}
+	return records, nil
 }
 
-// ClearEnvSetting clears environment settings for the given environment names.
-// It takes a variadic parameter of environment names and returns an error, if any.
-func ClearEnvSetting(envNames ...string) error {
-	// Iterate over each environment name
-	for _, name := range envNames {
-		// Unset the environment variable
-		if err := os.Unsetenv(name); err != nil {
-			return err
+// ReadEnvFS reads the contents of an env file from fsys into a map.
+//
+// It behaves like ReadEnvFile but opens the file through the fs.FS interface, which
+// allows reading configuration embedded with go:embed.
+func ReadEnvFS(fsys fs.FS, filename string) (map[string]string, error) {
+	f, err := fsys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, err := parseLines(f)
+	if err != nil {
+		return nil, err
+	}
+
+	envMap := make(map[string]string)
+	for _, l := range lines {
+		envMap[l.key] = l.value
+	}
+	return envMap, nil
+}
+
+// LoadFS reads env files from fsys and sets the environment variables accordingly.
+//
+// Files are merged in order, so later files override earlier ones. If no filenames
+// are provided, ".env" is read.
+func LoadFS(fsys fs.FS, filenames ...string) error {
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+
+	envMap := make(map[string]string)
+	for _, filename := range filenames {
+		tempEnvMap, err := ReadEnvFS(fsys, filename)
+		if err != nil {
+			return fmt.Errorf("failed to read env file: %w", err)
 		}
+		envMap = mergeMaps(envMap, tempEnvMap)
+	}
+
+	if err := SetenvMap(envMap); err != nil {
+		return fmt.Errorf("failed to set environment variables: %w", err)
 	}
-	// Return nil if no error occurred
 	return nil
 }
+
+// GetenvFirst returns the value of the first of keys that is set in the environment.
+//
+// It is intended for renamed settings that keep supporting older aliases, e.g.
+// GetenvFirst("DATABASE_URL", "DB_URL", "POSTGRES_URL"). found names the key that
+// matched, and ok is false if none of the keys is set.
+func GetenvFirst(keys ...string) (value string, found string, ok bool) {
+	for _, key := range keys {
+		if v, set := os.LookupEnv(key); set {
+			return v, key, true
+		}
+	}
+	return "", "", false
+}
+
+// GetenvColor parses the environment variable named by key as a hex color code.
+//
+// The `RGB`, `RRGGBB` and `RRGGBBAA` forms are accepted with or without a leading `#`.
+// Colors without an alpha component are fully opaque. An error is returned if the
+// variable is not set or is not a valid color code.
+func GetenvColor(key string) (color.RGBA, error) {
+	value := strings.TrimPrefix(os.Getenv(key), "#")
+	if value == "" {
+		return color.RGBA{}, fmt.Errorf("environment variable %s not set", key)
+	}
+
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) == 6 {
+		value += "ff"
+	}
+	if len(value) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color in environment variable %s: expected 3, 6 or 8 hex digits", key)
+	}
+
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color in environment variable %s: %w", key, err)
+	}
+	return color.RGBA{R: b[0], G: b[1], B: b[2], A: b[3]}, nil
+}
+
+// MustLoad is like Load but panics if any env file cannot be read or applied.
+//
+// It is intended for small programs that load their configuration at the top of main.
+// The panic message names the offending file and the underlying error.
+func MustLoad(filenames ...string) {
+	if err := Load(filenames...); err != nil {
+		panic("feng: MustLoad: " + err.Error())
+	}
+}
+
+// GetenvWeekdays parses a comma separated list of weekday names from the environment
+// variable named by key, e.g. "Mon,Wed,Fri".
+//
+// Both short ("mon") and long ("monday") names are accepted, case-insensitively. An
+// error naming the token is returned for an unrecognized day. An unset variable
+// returns an empty slice.
+func GetenvWeekdays(key string) ([]time.Weekday, error) {
+	days := []time.Weekday{}
+	for _, token := range GetenvStringSlice(key, ",") {
+		if token == "" {
+			continue
+		}
+		day, ok := parseWeekday(token)
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q in environment variable %s", token, key)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// parseWeekday matches name against the short and long English weekday names.
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		long := d.String()
+		if strings.EqualFold(name, long) || strings.EqualFold(name, long[:3]) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// quantitySuffixes lists the unit suffixes accepted by GetenvQuantity, following
+// Kubernetes resource quantities.
+var quantitySuffixes = map[string]bool{
+	"": true, "n": true, "u": true, "m": true,
+	"k": true, "M": true, "G": true, "T": true, "P": true, "E": true,
+	"Ki": true, "Mi": true, "Gi": true, "Ti": true, "Pi": true, "Ei": true,
+}
+
+// GetenvQuantity parses a Kubernetes-style resource quantity such as "512Mi" or "250m"
+// from the environment variable named by key.
+//
+// The numeric magnitude and the unit suffix are returned separately, so "250m" yields
+// 250 and "m", leaving the interpretation of the unit to the caller. Decimal (n, u, m,
+// k, M, G, T, P, E) and binary (Ki, Mi, Gi, Ti, Pi, Ei) suffixes are accepted.
+func GetenvQuantity(key string) (float64, string, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, "", fmt.Errorf("environment variable %s not set", key)
+	}
+
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= '0' && r <= '9') && r != '.' && r != '+' && r != '-'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+	number, suffix := value[:i], value[i:]
+
+	if !quantitySuffixes[suffix] {
+		return 0, "", fmt.Errorf("invalid quantity suffix %q in environment variable %s", suffix, key)
+	}
+	magnitude, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to parse environment variable %s as quantity: %w", key, err)
+	}
+	return magnitude, suffix, nil
+}
+
+// GetenvMapWithDefaults returns defaults overlaid with the environment variables
+// starting with prefix.
+//
+// Keys present in both take the environment value, keys only in defaults are
+// retained and keys only in the environment are added. defaults is not modified.
+func GetenvMapWithDefaults(prefix string, defaults map[string]string) map[string]string {
+	return mergeMaps(defaults, GetenvMap(prefix))
+}
+
+// GetenvSet splits the value of the environment variable named by key on sep and
+// returns the entries as a set.
+//
+// Entries are trimmed of surrounding whitespace, empty entries are skipped and
+// duplicates collapse into one. An unset or empty variable returns an empty set.
+func GetenvSet(key, sep string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, item := range GetenvStringSlice(key, sep) {
+		if item != "" {
+			set[item] = struct{}{}
+		}
+	}
+	return set
+}
+
+// Contains reports whether item is a member of set.
+func Contains(set map[string]struct{}, item string) bool {
+	_, ok := set[item]
+	return ok
+}
+
+// GetenvFileContents reads the file whose path is stored in the environment variable
+// named by key and returns its contents.
+//
+// This supports the common secrets pattern where e.g. TLS_CERT_FILE names a mounted
+// file. An error is returned if the variable is not set or the file cannot be read.
+func GetenvFileContents(key string) ([]byte, error) {
+	path := os.Getenv(key)
+	if path == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from environment variable %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// GetenvStringOrFile returns the value of the environment variable named by key, or,
+// if it is not set, the contents of the file named by key + "_FILE".
+//
+// Trailing newlines are removed from file contents. An error is returned if neither
+// variable is set or the file cannot be read.
+func GetenvStringOrFile(key string) (string, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, nil
+	}
+	if _, ok := os.LookupEnv(key + "_FILE"); !ok {
+		return "", fmt.Errorf("environment variable %s not set", key)
+	}
+	data, err := GetenvFileContents(key + "_FILE")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// GetenvCommaInt parses an integer written with comma thousands separators, such as
+// "1,000,000", from the environment variable named by key.
+//
+// Values without separators are accepted as well. See GetenvGroupedInt for the
+// grouping rules.
+func GetenvCommaInt(key string) (int64, error) {
+	return GetenvGroupedInt(key, ",")
+}
+
+// GetenvGroupedInt parses an integer written with the thousands separator sep, such as
+// "1.000.000" for sep ".", from the environment variable named by key.
+//
+// When separators are present the first group must have one to three digits and every
+// following group exactly three, so misplaced separators like "1,00,0" are rejected.
+func GetenvGroupedInt(key, sep string) (int64, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+
+	digits := strings.TrimLeft(value, "+-")
+	if sep != "" && strings.Contains(digits, sep) {
+		groups := strings.Split(digits, sep)
+		for i, g := range groups {
+			if (i == 0 && (len(g) < 1 || len(g) > 3)) || (i > 0 && len(g) != 3) {
+				return 0, fmt.Errorf("misplaced group separator in environment variable %s: %q", key, value)
+			}
+		}
+		value = strings.ReplaceAll(value, sep, "")
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as int64: %w", key, err)
+	}
+	return n, nil
+}
+
+// GetenvEnabled reports whether a feature is enabled by a FEATURE_ENABLED-style flag.
+//
+// The value is parsed with the extended grammar of GetenvTristate, so "on", "yes" and
+// "enabled" are true and "off", "no" and "disabled" are false. If the variable is
+// unset or cannot be parsed, defaultEnabled is returned, so GetenvEnabled(key, true)
+// means "on unless explicitly turned off". It is equivalent to GetenvBoolDefault.
+func GetenvEnabled(key string, defaultEnabled bool) bool {
+	return GetenvBoolDefault(key, defaultEnabled)
+}
+
+// GetenvDisabled reports whether a feature is enabled by a FEATURE_DISABLED-style flag,
+// i.e. it returns the inverse of the flag's value.
+//
+// The value is parsed like GetenvEnabled. If the variable is unset or cannot be
+// parsed, defaultEnabled is returned, keeping both helpers phrased in terms of the
+// feature rather than the flag.
+func GetenvDisabled(key string, defaultEnabled bool) bool {
+	return !GetenvBoolDefault(key, !defaultEnabled)
+}
+
+// GetenvValues returns the values of the environment variables starting with prefix,
+// ordered by their keys.
+//
+// Keys are compared lexically, byte by byte, so ENDPOINT_10 sorts before ENDPOINT_2.
+// Pad numeric suffixes with zeros (ENDPOINT_02) when numeric order matters.
+func GetenvValues(prefix string) []string {
+	envMap := GetenvMap(prefix)
+	values := make([]string, 0, len(envMap))
+	for _, k := range sortedKeys(envMap) {
+		values = append(values, envMap[k])
+	}
+	return values
+}
+
+// GetenvDuration parses the environment variable named by key with
+// time.ParseDuration, e.g. TIMEOUT=1h30m.
+//
+// Surrounding whitespace is trimmed. An error is returned if the variable is not set
+// or is not a valid duration.
+func GetenvDuration(key string) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as duration: %w", key, err)
+	}
+	return d, nil
+}
+
+// GetenvDurationOrDefault behaves like GetenvDuration but returns def when the
+// variable is not set. A set but invalid value is still an error, so typos are not
+// silently replaced by the default.
+func GetenvDurationOrDefault(key string, def time.Duration) (time.Duration, error) {
+	if strings.TrimSpace(os.Getenv(key)) == "" {
+		return def, nil
+	}
+	return GetenvDuration(key)
+}
+
+// GetenvDurationClamped parses the environment variable named by key with
+// time.ParseDuration and clamps the result into [min, max].
+//
+// Signed values such as "-5s" are accepted and clamped like any other. An error is
+// returned if the variable is not set or is not a valid duration.
+func GetenvDurationClamped(key string, min, max time.Duration) (time.Duration, error) {
+	return GetenvDurationBounded(key, min, max, true)
+}
+
+// GetenvDurationBounded parses the environment variable named by key as a duration and
+// checks it against [min, max].
+//
+// If clamp is true, out-of-range values are clamped to the nearest bound; otherwise an
+// error describing the allowed range is returned.
+func GetenvDurationBounded(key string, min, max time.Duration, clamp bool) (time.Duration, error) {
+	if min > max {
+		return 0, fmt.Errorf("invalid bounds for %s: min %v is greater than max %v", key, min, max)
+	}
+	d, err := GetenvDuration(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if d >= min && d <= max {
+		return d, nil
+	}
+	if !clamp {
+		return 0, fmt.Errorf("environment variable %s is %v, outside the allowed range [%v, %v]", key, d, min, max)
+	}
+	if d < min {
+		return min, nil
+	}
+	return max, nil
+}
+
+// GetenvMapGroups groups the environment variables starting with prefix by the
+// segment that follows it.
+//
+// The prefix is stripped and the remainder is split once on delim: the first part
+// names the group and the rest is the key within it. With prefix "DB_" and delim "_",
+// DB_PRIMARY_HOST becomes {"PRIMARY": {"HOST": ...}}. Keys without a further
+// delimiter, like DB_TIMEOUT, are placed in the "" group.
+func GetenvMapGroups(prefix, delim string) map[string]map[string]string {
+	groups := make(map[string]map[string]string)
+	for k, v := range GetenvMap(prefix) {
+		rest := strings.TrimPrefix(k, prefix)
+		group, name, ok := strings.Cut(rest, delim)
+		if !ok {
+			group, name = "", rest
+		}
+		if groups[group] == nil {
+			groups[group] = make(map[string]string)
+		}
+		groups[group][name] = v
+	}
+	return groups
+}
+
+// GetenvMillis parses the environment variable named by key as a whole number of
+// milliseconds, e.g. TIMEOUT_MS=1500, and returns it as a time.Duration. Values too
+// large for a time.Duration (about 292 years) are an error.
+func GetenvMillis(key string) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as milliseconds: %w", key, err)
+	}
+	if ms > math.MaxInt64/int64(time.Millisecond) || ms < math.MinInt64/int64(time.Millisecond) {
+		return 0, fmt.Errorf("environment variable %s is out of range for a duration: %s milliseconds", key, value)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// GetenvSeconds parses the environment variable named by key as a number of seconds,
+// e.g. TTL_SECONDS=30, and returns it as a time.Duration. Fractional values such as
+// "1.5" are accepted. NaN, infinities and values too large for a time.Duration (about
+// 292 years) are an error.
+func GetenvSeconds(key string) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	secs, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as seconds: %w", key, err)
+	}
+	if math.IsNaN(secs) || math.IsInf(secs, 0) {
+		return 0, fmt.Errorf("environment variable %s must be a finite number of seconds, got %s", key, value)
+	}
+	d := secs * float64(time.Second)
+	if d >= math.MaxInt64 || d < math.MinInt64 {
+		return 0, fmt.Errorf("environment variable %s is out of range for a duration: %s seconds", key, value)
+	}
+	return time.Duration(d), nil
+}
+
+// GetenvRatio parses a ratio such as "16:9" from the environment variable named by key.
+//
+// Both sides must be integers and the denominator must not be zero.
+func GetenvRatio(key string) (num, denom int, err error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	left, right, ok := strings.Cut(value, ":")
+	if !ok || left == "" || right == "" {
+		return 0, 0, fmt.Errorf("invalid ratio in environment variable %s: expected num:denom, got %q", key, value)
+	}
+	if num, err = strconv.Atoi(strings.TrimSpace(left)); err != nil {
+		return 0, 0, fmt.Errorf("invalid ratio numerator in environment variable %s: %w", key, err)
+	}
+	if denom, err = strconv.Atoi(strings.TrimSpace(right)); err != nil {
+		return 0, 0, fmt.Errorf("invalid ratio denominator in environment variable %s: %w", key, err)
+	}
+	if denom == 0 {
+		return 0, 0, fmt.Errorf("invalid ratio in environment variable %s: zero denominator", key)
+	}
+	return num, denom, nil
+}
+
+// GetenvEmail validates the email address stored in the environment variable named by
+// key with net/mail and returns the bare address.
+//
+// Display-name forms such as "Ops <ops@example.com>" are accepted and normalized to
+// "ops@example.com".
+func GetenvEmail(key string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s not set", key)
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address in environment variable %s: %w", key, err)
+	}
+	return addr.Address, nil
+}
+
+// GetenvEmails validates a comma separated list of email addresses stored in the
+// environment variable named by key and returns the bare addresses.
+func GetenvEmails(key string) ([]string, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	addrs, err := mail.ParseAddressList(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address list in environment variable %s: %w", key, err)
+	}
+	emails := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		emails = append(emails, a.Address)
+	}
+	return emails, nil
+}
+
+// GetenvProbability parses the environment variable named by key as a probability,
+// e.g. TRACE_SAMPLE=0.05, and checks that it lies within [0, 1].
+func GetenvProbability(key string) (float64, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	p, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as float64: %w", key, err)
+	}
+	if err := checkProbability(p); err != nil {
+		return 0, fmt.Errorf("invalid environment variable %s: %w", key, err)
+	}
+	return p, nil
+}
+
+// GetenvProbabilityOrDefault behaves like GetenvProbability but returns def when the
+// variable is not set. def itself must be within [0, 1], so a bad default is caught
+// as early as a bad setting.
+func GetenvProbabilityOrDefault(key string, def float64) (float64, error) {
+	if err := checkProbability(def); err != nil {
+		return 0, fmt.Errorf("invalid default for %s: %w", key, err)
+	}
+	if strings.TrimSpace(os.Getenv(key)) == "" {
+		return def, nil
+	}
+	return GetenvProbability(key)
+}
+
+// checkProbability returns an error if p is outside [0, 1] or NaN.
+func checkProbability(p float64) error {
+	if !(p >= 0 && p <= 1) {
+		return fmt.Errorf("probability %v is outside [0, 1]", p)
+	}
+	return nil
+}
+
+// GetenvStringSliceDefaults splits the value of the environment variable named by key
+// like GetenvStringSlice and fills missing positions from defaults.
+//
+// With defaults ["10", "20"], "5" yields ["5", "20"] and an unset variable yields
+// ["10", "20"]. Empty elements, as in "5,", also take the default for their position.
+// Elements beyond len(defaults) are kept as they are.
+func GetenvStringSliceDefaults(key, sep string, defaults []string) []string {
+	values := GetenvStringSlice(key, sep)
+	for i, d := range defaults {
+		if i >= len(values) {
+			values = append(values, d)
+		} else if values[i] == "" {
+			values[i] = d
+		}
+	}
+	return values
+}
+
+// parseBoolExtended parses s as a boolean, case-insensitively.
+//
+// Besides the forms accepted by strconv.ParseBool it understands yes/no, y/n, on/off
+// and enabled/disabled, which are common in operator-written configuration.
+func parseBoolExtended(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "t", "true", "y", "yes", "on", "enabled":
+		return true, nil
+	case "0", "f", "false", "n", "no", "off", "disabled":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean value %q", s)
+}
+
+// GetenvTristate reads a flag that may be unset, distinguishing unset from false.
+//
+// set reports whether the variable is present with a non-empty value, and value holds
+// the parsed boolean. Values are parsed with an extended grammar that accepts
+// true/false, 1/0, t/f, yes/no, y/n, on/off and enabled/disabled in any case. An error
+// is returned for a set value outside that grammar.
+func GetenvTristate(key string) (value bool, set bool, err error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return false, false, nil
+	}
+	value, err = parseBoolExtended(raw)
+	if err != nil {
+		return false, true, fmt.Errorf("failed to parse environment variable %s as bool: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// GetenvVerifiedBytes reads the environment variable named by valueKey and checks it
+// against the hex-encoded SHA-256 digest stored in sumKey.
+//
+// A value prefixed with "base64:" is decoded before hashing; any other value is used
+// as is. The bytes are returned only if their digest matches, otherwise an error is
+// returned. Both variables must be set.
+func GetenvVerifiedBytes(valueKey, sumKey string) ([]byte, error) {
+	value, ok := os.LookupEnv(valueKey)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s not set", valueKey)
+	}
+	sum := strings.TrimSpace(os.Getenv(sumKey))
+	if sum == "" {
+		return nil, fmt.Errorf("environment variable %s not set", sumKey)
+	}
+	expected, err := hex.DecodeString(sum)
+	if err != nil || len(expected) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 digest in environment variable %s", sumKey)
+	}
+
+	data := []byte(value)
+	if strings.HasPrefix(value, "base64:") {
+		if data, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(value, "base64:")); err != nil {
+			return nil, fmt.Errorf("failed to decode environment variable %s as base64: %w", valueKey, err)
+		}
+	}
+
+	actual := sha256.Sum256(data)
+	if subtle.ConstantTimeCompare(actual[:], expected) != 1 {
+		return nil, fmt.Errorf("checksum mismatch for environment variable %s: expected %s, got %x", valueKey, strings.ToLower(sum), actual)
+	}
+	return data, nil
+}
+
+// GetenvDecimal parses the environment variable named by key as a fixed-point decimal,
+// e.g. PRICE=19.99, without going through floating point.
+//
+// The value is returned as an integer mantissa and the number of digits after the
+// decimal point, so "19.99" yields (1999, 2) and "5" yields (5, 0). Trailing zeros are
+// kept: "19.90" yields (1990, 2). An optional leading sign is accepted. An error is
+// returned if the variable is not set, is malformed or does not fit in an int64.
+func GetenvDecimal(key string) (units int64, scale int, err error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, 0, fmt.Errorf("environment variable %s not set", key)
+	}
+
+	digits := strings.TrimLeft(value, "+-")
+	whole, frac, hasPoint := strings.Cut(digits, ".")
+	if len(value)-len(digits) > 1 || whole == "" || (hasPoint && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return 0, 0, fmt.Errorf("failed to parse environment variable %s as decimal: invalid syntax %q", key, value)
+	}
+
+	units, err = strconv.ParseInt(value[:len(value)-len(digits)]+whole+frac, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse environment variable %s as decimal: %w", key, err)
+	}
+	return units, len(frac), nil
+}
+
+// isDigits reports whether s consists only of ASCII digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// GetenvRetryPolicy parses a compact retry specification of the form
+// `<attempts>x<backoff>` from the environment variable named by key, e.g. RETRY=5x2s
+// for five attempts with a two second backoff.
+//
+// The attempt count must be a positive integer and the backoff a non-negative duration
+// accepted by time.ParseDuration. The error names the part that is missing or invalid.
+func GetenvRetryPolicy(key string) (attempts int, backoff time.Duration, err error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, 0, fmt.Errorf("environment variable %s not set", key)
+	}
+
+	count, delay, ok := strings.Cut(value, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid retry policy in environment variable %s: expected <attempts>x<backoff>, got %q", key, value)
+	}
+	attempts, err = strconv.Atoi(count)
+	if err != nil || attempts <= 0 {
+		return 0, 0, fmt.Errorf("invalid retry policy in environment variable %s: attempts %q is not a positive integer", key, count)
+	}
+	backoff, err = time.ParseDuration(delay)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid retry policy in environment variable %s: backoff: %w", key, err)
+	}
+	if backoff < 0 {
+		return 0, 0, fmt.Errorf("invalid retry policy in environment variable %s: negative backoff %s", key, backoff)
+	}
+	return attempts, backoff, nil
+}
+
+// GetenvBitSize parses the environment variable named by key as a bit width, e.g.
+// RSA_BITS=2048.
+//
+// The value must be a positive decimal integer. If allowed is non-empty, the value
+// must also be one of the listed sizes and the error lists them otherwise.
+func GetenvBitSize(key string, allowed ...int) (int, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	bits, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as bit size: %w", key, err)
+	}
+	if bits <= 0 {
+		return 0, fmt.Errorf("invalid bit size %d in environment variable %s: must be positive", bits, key)
+	}
+	if len(allowed) == 0 {
+		return bits, nil
+	}
+
+	sizes := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		if a == bits {
+			return bits, nil
+		}
+		sizes = append(sizes, strconv.Itoa(a))
+	}
+	return 0, fmt.Errorf("invalid bit size %d in environment variable %s: allowed sizes are %s", bits, key, strings.Join(sizes, ", "))
+}
+
+// GetenvValidated retrieves the environment variable named by key and checks it with
+// validate.
+//
+// The value is returned unchanged if validate accepts it. Otherwise the validator's
+// error is returned, prefixed with the variable name so it can be traced back to its
+// source. An error is also returned if the variable is not set.
+func GetenvValidated(key string, validate func(string) error) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s not set", key)
+	}
+	if err := validate(value); err != nil {
+		return "", fmt.Errorf("invalid environment variable %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// CountSet returns how many of keys are set to a non-empty value.
+//
+// It supports rules such as "exactly one of A, B or C must be set"; see ExactlyOneSet.
+func CountSet(keys ...string) int {
+	n := 0
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok && v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// ExactlyOneSet returns an error unless exactly one of keys is set to a non-empty
+// value. The error names the keys that are set, or all keys if none is.
+func ExactlyOneSet(keys ...string) error {
+	var set []string
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok && v != "" {
+			set = append(set, k)
+		}
+	}
+	switch len(set) {
+	case 1:
+		return nil
+	case 0:
+		return fmt.Errorf("exactly one of %s must be set, but none is", strings.Join(keys, ", "))
+	}
+	return fmt.Errorf("exactly one of %s must be set, but %s are", strings.Join(keys, ", "), strings.Join(set, ", "))
+}
+
+// GetenvMapApply retrieves the environment variables starting with prefix and passes
+// each key and value through fn, storing the value it returns.
+//
+// Keys are processed in sorted order and the first error stops processing; it is
+// returned annotated with the offending key.
+func GetenvMapApply(prefix string, fn func(key, value string) (string, error)) (map[string]string, error) {
+	envMap := GetenvMap(prefix)
+	for _, k := range sortedKeys(envMap) {
+		v, err := fn(k, envMap[k])
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform environment variable %s: %w", k, err)
+		}
+		envMap[k] = v
+	}
+	return envMap, nil
+}
+
+// GetenvHostPort splits the environment variable named by key into a host and a port,
+// e.g. LISTEN=0.0.0.0:8080. IPv6 hosts must be bracketed, as in "[::1]:443", and are
+// returned without the brackets.
+//
+// The host may be empty, as in ":8080". An error is returned if the variable is not
+// set, has no port, or its port is not a number between 0 and 65535.
+func GetenvHostPort(key string) (host string, port int, err error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return "", 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	host, p, err := net.SplitHostPort(value)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid address in environment variable %s: %w", key, err)
+	}
+	port, err = strconv.Atoi(p)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q in environment variable %s", p, key)
+	}
+	if port < 0 || port > 65535 {
+		return "", 0, fmt.Errorf("port %d in environment variable %s out of range 0-65535", port, key)
+	}
+	return host, port, nil
+}
+
+// RequireIf checks that every one of requiredKeys is set to a non-empty value when the
+// flag condKey is enabled, e.g. RequireIf("TLS_ENABLED", "TLS_CERT", "TLS_KEY").
+//
+// condKey is parsed with the same extended grammar as GetenvTristate; an unset or
+// false condition makes RequireIf a no-op. All missing keys are reported together in
+// a single error.
+func RequireIf(condKey string, requiredKeys ...string) error {
+	enabled, _, err := GetenvTristate(condKey)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	var missing []string
+	for _, k := range requiredKeys {
+		if os.Getenv(k) == "" {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s is enabled but required environment variables are not set: %s", condKey, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// GetenvJSONSlice unmarshals the environment variable named by key as a JSON array of
+// T, e.g. PORTS=[80,443,8080].
+//
+// An error is returned if the variable is not set or does not hold a JSON array whose
+// elements decode into T.
+func GetenvJSONSlice[T any](key string) ([]T, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	var items []T
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return nil, fmt.Errorf("failed to parse environment variable %s as JSON array: %w", key, err)
+	}
+	if items == nil {
+		return nil, fmt.Errorf("failed to parse environment variable %s as JSON array: got null", key)
+	}
+	return items, nil
+}
+
+// GetenvOrFunc returns the value of the environment variable named by key, calling fn
+// to compute a default only when the variable is not set or empty.
+//
+// This keeps expensive fallbacks, such as reading a file or calling a service, off the
+// common path. An error from fn is returned as is.
+func GetenvOrFunc(key string, fn func() (string, error)) (string, error) {
+	if value := os.Getenv(key); value != "" {
+		return value, nil
+	}
+	return fn()
+}
+
+// GetenvIntRange parses an inclusive integer range of the form `min-max` from the
+// environment variable named by key, e.g. PORT_RANGE=3000-3010.
+//
+// Either bound may be negative: the separator is the first dash after the first
+// character, so "-5-5" yields (-5, 5) and "-10--2" yields (-10, -2). An error is
+// returned if a bound is missing or not a number, or if min is greater than max.
+func GetenvIntRange(key string) (min, max int, err error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, 0, fmt.Errorf("environment variable %s not set", key)
+	}
+
+	i := strings.IndexByte(value[1:], '-') + 1
+	if i == 0 {
+		return 0, 0, fmt.Errorf("invalid range in environment variable %s: expected min-max, got %q", key, value)
+	}
+	lo, hi := strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:])
+	if min, err = strconv.Atoi(lo); err != nil {
+		return 0, 0, fmt.Errorf("invalid range in environment variable %s: lower bound %q is not a number", key, lo)
+	}
+	if max, err = strconv.Atoi(hi); err != nil {
+		return 0, 0, fmt.Errorf("invalid range in environment variable %s: upper bound %q is not a number", key, hi)
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("invalid range in environment variable %s: lower bound %d is greater than upper bound %d", key, min, max)
+	}
+	return min, max, nil
+}
+
+// AppendToPathVar appends entry to the PATH-like list held by the environment variable
+// named by key, unless entry is already one of its elements.
+//
+// Elements are separated by sep, or by os.PathListSeparator if sep is empty. An unset
+// or empty variable is set to entry alone.
+func AppendToPathVar(key, entry, sep string) error {
+	return addToPathVar(key, entry, sep, false)
+}
+
+// PrependToPathVar is like AppendToPathVar but adds entry at the front of the list.
+func PrependToPathVar(key, entry, sep string) error {
+	return addToPathVar(key, entry, sep, true)
+}
+
+// addToPathVar adds entry to the list in key at the front or back.
+func addToPathVar(key, entry, sep string, front bool) error {
+	if sep == "" {
+		sep = string(os.PathListSeparator)
+	}
+	current := os.Getenv(key)
+	if current == "" {
+		return os.Setenv(key, entry)
+	}
+	for _, e := range strings.Split(current, sep) {
+		if e == entry {
+			return nil
+		}
+	}
+	if front {
+		return os.Setenv(key, entry+sep+current)
+	}
+	return os.Setenv(key, current+sep+entry)
+}
+
+// GetenvBoolDefault reads a flag from the environment variable named by key, returning
+// def when the variable is unset, empty or not a recognized boolean.
+//
+// Values are parsed with the same extended grammar as GetenvTristate, so "yes", "on"
+// and "enabled" are all true. It suits feature flags that default to enabled.
+func GetenvBoolDefault(key string, def bool) bool {
+	value, set, err := GetenvTristate(key)
+	if !set || err != nil {
+		return def
+	}
+	return value
+}
+
+// MapFromPairs builds a map from alternating keys and values, e.g.
+// MapFromPairs("HOST", "localhost", "PORT", "8080"), for use with SetenvMap or
+// WriteEnvFileMap.
+//
+// An error is returned for an odd number of arguments or a key given twice.
+func MapFromPairs(pairs ...string) (map[string]string, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("odd number of arguments: key %s has no value", pairs[len(pairs)-1])
+	}
+	m := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		if _, dup := m[pairs[i]]; dup {
+			return nil, fmt.Errorf("duplicate key %s", pairs[i])
+		}
+		m[pairs[i]] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// GetenvDecrypted reads base64-encoded ciphertext from the environment variable named
+// by key and returns the plaintext produced by decrypt.
+//
+// The package does no cryptography itself: decrypt receives the decoded bytes and may
+// call a KMS or any other service. An error is returned if the variable is not set, is
+// not valid standard base64, or decrypt fails.
+func GetenvDecrypted(key string, decrypt func([]byte) ([]byte, error)) (string, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s not set", key)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode environment variable %s as base64: %w", key, err)
+	}
+	plaintext, err := decrypt(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt environment variable %s: %w", key, err)
+	}
+	return string(plaintext), nil
+}
+
+// GetenvMapDelta compares the variables under prefixA with those under prefixB, e.g.
+// OLD_ and NEW_, after stripping each prefix from the keys.
+//
+// onlyA and onlyB hold the keys found under one prefix only, with their values.
+// differing holds the keys present under both prefixes with different values, mapped
+// to the value under prefixB. All three maps use the stripped keys and are never nil.
+func GetenvMapDelta(prefixA, prefixB string) (onlyA, onlyB, differing map[string]string) {
+	a := stripKeyPrefix(GetenvMap(prefixA), prefixA)
+	b := stripKeyPrefix(GetenvMap(prefixB), prefixB)
+
+	onlyA = make(map[string]string)
+	onlyB = make(map[string]string)
+	differing = make(map[string]string)
+	for k, va := range a {
+		vb, ok := b[k]
+		switch {
+		case !ok:
+			onlyA[k] = va
+		case va != vb:
+			differing[k] = vb
+		}
+	}
+	for k, vb := range b {
+		if _, ok := a[k]; !ok {
+			onlyB[k] = vb
+		}
+	}
+	return onlyA, onlyB, differing
+}
+
+// stripKeyPrefix returns a copy of m with prefix removed from every key.
+func stripKeyPrefix(m map[string]string, prefix string) map[string]string {
+	stripped := make(map[string]string, len(m))
+	for k, v := range m {
+		stripped[strings.TrimPrefix(k, prefix)] = v
+	}
+	return stripped
+}
+
+// GetenvMIMEType parses the environment variable named by key as a media type with
+// mime.ParseMediaType, so "text/plain; charset=utf-8" yields "text/plain" and
+// {"charset": "utf-8"}.
+//
+// The media type is returned in lower case. An error is returned if the variable is
+// not set or is malformed.
+func GetenvMIMEType(key string) (mediatype string, params map[string]string, err error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return "", nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	mediatype, params, err = mime.ParseMediaType(value)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse environment variable %s as media type: %w", key, err)
+	}
+	return mediatype, params, nil
+}
+
+// GetenvMapNormalized retrieves the environment variables starting with prefix with
+// every key passed through canon, e.g. strings.ToUpper.
+//
+// If two distinct variables canonicalize to the same key, such as "Path" and "PATH"
+// under strings.ToUpper, no map is returned and the error lists every collision.
+func GetenvMapNormalized(prefix string, canon func(string) string) (map[string]string, error) {
+	envMap := GetenvMap(prefix)
+	normalized := make(map[string]string, len(envMap))
+	sources := make(map[string][]string, len(envMap))
+	for _, k := range sortedKeys(envMap) {
+		ck := canon(k)
+		sources[ck] = append(sources[ck], k)
+		normalized[ck] = envMap[k]
+	}
+
+	var collisions []string
+	for _, ck := range sortedKeys(normalized) {
+		if len(sources[ck]) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s (from %s)", ck, strings.Join(sources[ck], ", ")))
+		}
+	}
+	if len(collisions) > 0 {
+		return nil, fmt.Errorf("key collisions after normalization: %s", strings.Join(collisions, "; "))
+	}
+	return normalized, nil
+}
+
+// GetenvTopicSpecs parses comma separated `name:number` pairs from the environment
+// variable named by key, e.g. TOPICS=orders:3,payments:1, into a map from topic name to
+// number.
+//
+// Names and numbers are trimmed and empty items are skipped. The number must be a
+// positive integer. An error naming the entry is returned for a missing or invalid
+// number or a topic listed twice.
+func GetenvTopicSpecs(key string) (map[string]int, error) {
+	value := os.Getenv(key)
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+
+	specs := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, num, ok := strings.Cut(item, ":")
+		name, num = strings.TrimSpace(name), strings.TrimSpace(num)
+		if !ok || name == "" || num == "" {
+			return nil, fmt.Errorf("invalid topic spec %q in environment variable %s: expected name:number", item, key)
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid topic spec %q in environment variable %s: %q is not a positive integer", item, key, num)
+		}
+		if _, dup := specs[name]; dup {
+			return nil, fmt.Errorf("duplicate topic %s in environment variable %s", name, key)
+		}
+		specs[name] = n
+	}
+	return specs, nil
+}
+
+// RequireAllOrNone returns an error if some but not all of keys are set to a non-empty
+// value, e.g. RequireAllOrNone("SMTP_USER", "SMTP_PASS"). The error names the keys
+// that are missing. When every key is set, or none is, it returns nil.
+func RequireAllOrNone(keys ...string) error {
+	var missing []string
+	for _, k := range keys {
+		if os.Getenv(k) == "" {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 || len(missing) == len(keys) {
+		return nil
+	}
+	return fmt.Errorf("%s must be set together, but %s is not set", strings.Join(keys, ", "), strings.Join(missing, ", "))
+}
+
+// Pseudo-layouts accepted by GetenvTime for Unix timestamps.
+const (
+	// LayoutUnix parses the value as whole seconds since the Unix epoch.
+	LayoutUnix = "unix"
+	// LayoutUnixMilli parses the value as whole milliseconds since the Unix epoch.
+	LayoutUnixMilli = "unixmilli"
+)
+
+// GetenvTime parses the environment variable named by key as a point in time.
+//
+// time.RFC3339 is tried first, followed by layouts in order. Besides time.Parse
+// layouts, LayoutUnix and LayoutUnixMilli accept Unix timestamps in seconds and
+// milliseconds. An error listing the layouts tried is returned if the variable is not
+// set or none of them matches.
+func GetenvTime(key string, layouts ...string) (time.Time, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return time.Time{}, fmt.Errorf("environment variable %s not set", key)
+	}
+
+	tried := append([]string{time.RFC3339}, layouts...)
+	for _, layout := range tried {
+		switch layout {
+		case LayoutUnix, LayoutUnixMilli:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			if layout == LayoutUnix {
+				return time.Unix(n, 0), nil
+			}
+			return time.UnixMilli(n), nil
+		default:
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse environment variable %s as time: %q matches none of the layouts %q", key, value, tried)
+}
+
+// GetenvURL parses the environment variable named by key as an absolute URL of the
+// form scheme://host..., e.g. API_ENDPOINT=https://api.example.com/v1.
+//
+// If schemes is non-empty the URL's scheme must be one of them, compared
+// case-insensitively, e.g. GetenvURL("API_ENDPOINT", "https"). An error is returned if
+// the variable is not set, cannot be parsed, lacks a scheme or a host, or uses a
+// disallowed one. URLs without a host, such as file:///etc/app.conf, are rejected.
+func GetenvURL(key string, schemes ...string) (*url.URL, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse environment variable %s as URL: %w", key, err)
+	}
+	if u.Scheme == "" || u.Opaque != "" {
+		return nil, fmt.Errorf("invalid URL in environment variable %s: expected scheme://..., got %q", key, value)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid URL in environment variable %s: missing host in %q", key, value)
+	}
+	if len(schemes) == 0 {
+		return u, nil
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid URL in environment variable %s: scheme %q is not one of %s", key, u.Scheme, strings.Join(schemes, ", "))
+}
+
+// GetenvIP parses the environment variable named by key as an IPv4 or IPv6 address,
+// e.g. BIND_ADDR=10.0.0.5. An error is returned if the variable is not set or is not
+// a valid address.
+func GetenvIP(key string) (net.IP, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address in environment variable %s: %q", key, value)
+	}
+	return ip, nil
+}
+
+// GetenvCIDR parses the environment variable named by key as a subnet in CIDR
+// notation, e.g. ALLOWED_NET=10.0.0.0/8. The returned network has its host bits
+// cleared, so "10.1.2.3/8" yields 10.0.0.0/8. An error is returned if the variable is
+// not set or is not valid CIDR notation.
+func GetenvCIDR(key string) (*net.IPNet, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse environment variable %s as CIDR: %w", key, err)
+	}
+	return ipNet, nil
+}
+
+// GetenvIntSlice splits the value of the environment variable named by key on sep
+// and parses each trimmed element as an int, e.g. PORTS=80,443,8080.
+//
+// Splitting follows GetenvStringSlice, so a separator escaped with a backslash stays
+// part of its element and element indices match that function. Elements follow the
+// same base prefix rules as GetenvInt. The error names the index of the first element
+// that cannot be parsed. An unset or empty variable returns an empty slice.
+func GetenvIntSlice(key, sep string) ([]int, error) {
+	return getenvSlice(key, sep, "int", func(s string) (int, error) {
+		n, err := strconv.ParseInt(s, 0, strconv.IntSize)
+		return int(n), err
+	})
+}
+
+// GetenvInt64Slice is like GetenvIntSlice but parses each element as an int64.
+func GetenvInt64Slice(key, sep string) ([]int64, error) {
+	return getenvSlice(key, sep, "int64", func(s string) (int64, error) {
+		return strconv.ParseInt(s, 0, 64)
+	})
+}
+
+// getenvSlice splits the value of key like GetenvStringSlice, honouring escaped
+// separators, and converts each element with parse. typeName is used in error messages.
+func getenvSlice[T any](key, sep, typeName string, parse func(string) (T, error)) ([]T, error) {
+	parts := GetenvStringSlice(key, sep)
+	items := make([]T, 0, len(parts))
+	for i, p := range parts {
+		v, err := parse(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse element %d of environment variable %s as %s: %w", i, key, typeName, err)
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+// GetenvKVMap parses an inline map from the environment variable named by key, e.g.
+// LABELS=a=1,b=2,c=3 with pairSep "," and kvSep "=".
+//
+// Keys and values are trimmed and empty items are skipped; pairSep preceded by a
+// backslash is kept as a literal character. Only the first kvSep of a pair separates
+// key from value, so values may contain it. An error naming the pair is returned for
+// a pair without kvSep or with an empty key, or if either separator is empty. An
+// unset variable yields an empty map.
+func GetenvKVMap(key, pairSep, kvSep string) (map[string]string, error) {
+	if pairSep == "" || kvSep == "" {
+		return nil, errors.New("GetenvKVMap: separators must not be empty")
+	}
+	m, err := parseKeyValues(os.Getenv(key), pairSep, kvSep)
+	if err != nil {
+		return nil, fmt.Errorf("invalid environment variable %s: %w", key, err)
+	}
+	return m, nil
+}
+
+// GetenvJSON decodes the JSON value of the environment variable named by key into out,
+// which must be a pointer, as with json.Unmarshal.
+//
+// It suits platforms that inject service bindings or credentials as a single JSON
+// blob. An error is returned if the variable is not set or cannot be decoded into out.
+func GetenvJSON(key string, out interface{}) error {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fmt.Errorf("environment variable %s not set", key)
+	}
+	if err := json.Unmarshal([]byte(value), out); err != nil {
+		return fmt.Errorf("failed to parse environment variable %s as JSON: %w", key, err)
+	}
+	return nil
+}
+
+// GetenvBase64 decodes the environment variable named by key as standard, padded
+// base64, as used for keys, salts and HMAC secrets.
+//
+// An error is returned if the variable is not set or is not valid base64. Use
+// GetenvBase64With for URL-safe, unpadded or strict decoding.
+func GetenvBase64(key string) ([]byte, error) {
+	return GetenvBase64With(key, base64.StdEncoding)
+}
+
+// GetenvBase64With decodes the environment variable named by key with enc, e.g.
+// base64.URLEncoding, base64.RawURLEncoding or base64.StdEncoding.Strict().
+func GetenvBase64With(key string, enc *base64.Encoding) ([]byte, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	b, err := enc.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode environment variable %s as base64: %w", key, err)
+	}
+	return b, nil
+}
+
+// GetenvHex decodes the environment variable named by key as hexadecimal, in either
+// case. An error is returned if the variable is not set or is not valid hex.
+func GetenvHex(key string) ([]byte, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode environment variable %s as hex: %w", key, err)
+	}
+	return b, nil
+}
+
+// byteSizeUnits maps the lower-cased unit suffixes accepted by GetenvByteSize to
+// their size in bytes.
+var byteSizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1e3, "kb": 1e3, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gib": 1 << 30,
+	"t": 1e12, "tb": 1e12, "tib": 1 << 40,
+	"p": 1e15, "pb": 1e15, "pib": 1 << 50,
+}
+
+// GetenvByteSize parses a human-readable size such as "512KB", "10MiB" or "2G" from
+// the environment variable named by key and returns it in bytes.
+//
+// Units are case-insensitive and may be separated from the number by spaces. Decimal
+// units (K or KB, M or MB, up to P or PB) are powers of 1000 and binary units (KiB,
+// MiB, up to PiB) powers of 1024; a bare number or B means bytes. Fractions such as
+// "1.5GiB" are accepted and rounded down to a whole byte. An error is returned if the
+// variable is not set, is negative, has an unknown unit or overflows an int64.
+func GetenvByteSize(key string) (int64, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	if strings.HasPrefix(value, "-") {
+		return 0, fmt.Errorf("byte size in environment variable %s must not be negative, got %s", key, value)
+	}
+
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= '0' && r <= '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+	number, unit := value[:i], strings.ToLower(strings.TrimSpace(value[i:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit %q in environment variable %s", value[i:], key)
+	}
+
+	if !strings.Contains(number, ".") {
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse environment variable %s as byte size: %w", key, err)
+		}
+		if n > math.MaxInt64/multiplier {
+			return 0, fmt.Errorf("byte size in environment variable %s overflows int64", key)
+		}
+		return n * multiplier, nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse environment variable %s as byte size: %w", key, err)
+	}
+	size := f * float64(multiplier)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size in environment variable %s overflows int64", key)
+	}
+	return int64(size), nil
+}
+
+// InvalidUUIDError is returned by GetenvUUID when a variable does not hold a UUID.
+type InvalidUUIDError struct {
+	Key   string
+	Value string
+}
+
+// Error describes the variable and its malformed value.
+func (e *InvalidUUIDError) Error() string {
+	return fmt.Sprintf("invalid UUID in environment variable %s: %q", e.Key, e.Value)
+}
+
+// GetenvUUID reads a UUID from the environment variable named by key and returns it in
+// canonical form, lower case with dashes, e.g. "6ba7b810-9dad-11d1-80b4-00c04fd430c8".
+//
+// Besides the canonical form, values wrapped in braces, prefixed with "urn:uuid:" or
+// written as 32 hex digits without dashes are accepted, in any case. A malformed value
+// yields an *InvalidUUIDError; an unset variable yields a plain error.
+func GetenvUUID(key string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s not set", key)
+	}
+
+	s := strings.ToLower(value)
+	s = strings.TrimPrefix(s, "urn:uuid:")
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return "", &InvalidUUIDError{Key: key, Value: value}
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return "", &InvalidUUIDError{Key: key, Value: value}
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return "", &InvalidUUIDError{Key: key, Value: value}
+	}
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:], nil
+}
+
+// GetenvEnum reads the environment variable named by key and checks that its trimmed
+// value is exactly one of allowed, e.g. GetenvEnum("LOG_FORMAT", "json", "text").
+//
+// An error listing the allowed values is returned if the variable is not set or holds
+// any other value.
+func GetenvEnum(key string, allowed ...string) (string, error) {
+	return getenvEnum(key, allowed, func(a, b string) bool { return a == b })
+}
+
+// GetenvEnumFold is like GetenvEnum but compares case-insensitively. The matching
+// entry of allowed is returned, so "PROD" yields "prod" when "prod" is allowed.
+func GetenvEnumFold(key string, allowed ...string) (string, error) {
+	return getenvEnum(key, allowed, strings.EqualFold)
+}
+
+// getenvEnum returns the entry of allowed that equal reports as matching the value of
+// key.
+func getenvEnum(key string, allowed []string, equal func(a, b string) bool) (string, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s not set", key)
+	}
+	for _, a := range allowed {
+		if equal(value, a) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value %q in environment variable %s: must be one of %s", value, key, strings.Join(allowed, ", "))
+}
@@ -1,8 +1,20 @@
 package feng_test
 
 import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image/color"
+	"net"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/nosusume/feng"
 )
@@ -176,3 +188,2187 @@ func TestSetenvMap(t *testing.T) {
 		t.Errorf("Error setting environment variables: %v", err)
 	}
 }
+
+func TestGetenvPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"tilde", "~", home},
+		{"tilde subdirectory", "~/sub", filepath.Join(home, "sub")},
+		{"HOME reference", "${HOME}/logs", filepath.Join(home, "logs")},
+		{"relative path", "data/../cache", filepath.Join(cwd, "cache")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_TEST_PATH", tt.value)
+			got, err := feng.GetenvPath("FENG_TEST_PATH")
+			if err != nil {
+				t.Fatalf("GetenvPath returned an error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %s, but got %s", tt.want, got)
+			}
+		})
+	}
+
+	// Unset variable
+	if _, err := feng.GetenvPath("FENG_TEST_PATH_UNSET"); err == nil {
+		t.Error("Expected an error for an unset variable")
+	}
+}
+
+func TestGetenvMapJSON(t *testing.T) {
+	t.Setenv("FENGJSON_B", "2")
+	t.Setenv("FENGJSON_A", "1")
+	t.Setenv("FENGJSON_DB_PASSWORD", "hunter2")
+	t.Setenv("FENGJSON_KEYBOARD_LAYOUT", "us")
+
+	// Test case 1: Keys are sorted
+	got, err := feng.GetenvMapJSON("FENGJSON_")
+	if err != nil {
+		t.Fatalf("GetenvMapJSON returned an error: %v", err)
+	}
+	want := `{"FENGJSON_A":"1","FENGJSON_B":"2","FENGJSON_DB_PASSWORD":"hunter2","FENGJSON_KEYBOARD_LAYOUT":"us"}`
+	if string(got) != want {
+		t.Errorf("Expected %s, but got %s", want, got)
+	}
+
+	// Test case 2: Sensitive values are redacted, other keys containing KEY are not
+	got, err = feng.GetenvMapJSONRedacted("FENGJSON_")
+	if err != nil {
+		t.Fatalf("GetenvMapJSONRedacted returned an error: %v", err)
+	}
+	want = `{"FENGJSON_A":"1","FENGJSON_B":"2","FENGJSON_DB_PASSWORD":"****","FENGJSON_KEYBOARD_LAYOUT":"us"}`
+	if string(got) != want {
+		t.Errorf("Expected %s, but got %s", want, got)
+	}
+
+	// Test case 3: A custom pattern
+	got, err = feng.GetenvMapJSONRedactedWith("FENGJSON_", regexp.MustCompile(`^FENGJSON_[AK]`))
+	if err != nil {
+		t.Fatalf("GetenvMapJSONRedactedWith returned an error: %v", err)
+	}
+	want = `{"FENGJSON_A":"****","FENGJSON_B":"2","FENGJSON_DB_PASSWORD":"hunter2","FENGJSON_KEYBOARD_LAYOUT":"****"}`
+	if string(got) != want {
+		t.Errorf("Expected %s, but got %s", want, got)
+	}
+}
+
+func TestGetenvStringSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"no escapes", "a, b ,c", []string{"a", "b", "c"}},
+		{"escaped separator", `Smith\, John,Doe`, []string{"Smith, John", "Doe"}},
+		{"trailing escape", `a,b\,`, []string{"a", "b,"}},
+		{"lone backslash", `a\b,c`, []string{`a\b`, "c"}},
+		{"empty value", "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_TEST_SLICE", tt.value)
+			got := feng.GetenvStringSlice("FENG_TEST_SLICE", ",")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, but got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	t.Setenv("FENG_RELOAD_CHANGED", "old")
+	t.Setenv("FENG_RELOAD_SAME", "same")
+	t.Setenv("FENG_RELOAD_OTHER", "untouched")
+	t.Setenv("FENG_RELOAD_NEW", "")
+	os.Unsetenv("FENG_RELOAD_NEW")
+
+	filename := filepath.Join(t.TempDir(), ".env")
+	content := "FENG_RELOAD_CHANGED=new\nFENG_RELOAD_SAME=same\nFENG_RELOAD_NEW=added\n"
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	changed, err := feng.Reload(filename)
+	if err != nil {
+		t.Fatalf("Reload returned an error: %v", err)
+	}
+
+	expected := map[string]string{
+		"FENG_RELOAD_CHANGED": "new",
+		"FENG_RELOAD_NEW":     "added",
+	}
+	if !compareMap(expected, changed) {
+		t.Errorf("Expected changed keys %v, but got %v", expected, changed)
+	}
+	if got := os.Getenv("FENG_RELOAD_CHANGED"); got != "new" {
+		t.Errorf("Expected FENG_RELOAD_CHANGED=new, but got %s", got)
+	}
+	if got := os.Getenv("FENG_RELOAD_OTHER"); got != "untouched" {
+		t.Errorf("Expected FENG_RELOAD_OTHER to be left alone, but got %s", got)
+	}
+}
+
+func TestGetenvSince(t *testing.T) {
+	// Test case 1: Past timestamp
+	t.Setenv("FENG_STARTED_AT", time.Now().Add(-time.Hour).Format(time.RFC3339))
+	d, err := feng.GetenvSince("FENG_STARTED_AT")
+	if err != nil {
+		t.Fatalf("GetenvSince returned an error: %v", err)
+	}
+	if d < 59*time.Minute {
+		t.Errorf("Expected about an hour, but got %v", d)
+	}
+
+	// Test case 2: Future timestamp
+	t.Setenv("FENG_STARTED_AT", time.Now().Add(time.Hour).Format(time.RFC3339))
+	d, err = feng.GetenvSince("FENG_STARTED_AT")
+	if err != nil {
+		t.Fatalf("GetenvSince returned an error: %v", err)
+	}
+	if d >= 0 {
+		t.Errorf("Expected a negative duration, but got %v", d)
+	}
+
+	// Test case 3: Invalid value
+	t.Setenv("FENG_STARTED_AT", "yesterday")
+	if _, err := feng.GetenvSince("FENG_STARTED_AT"); err == nil {
+		t.Error("Expected an error for an invalid timestamp")
+	}
+}
+
+func TestGetenvMapInt(t *testing.T) {
+	// Test case 1: All values are numeric
+	t.Setenv("FENG_LIMIT_A", "10")
+	t.Setenv("FENG_LIMIT_B", "20")
+	got, err := feng.GetenvMapInt("FENG_LIMIT_")
+	if err != nil {
+		t.Fatalf("GetenvMapInt returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(got, map[string]int{"FENG_LIMIT_A": 10, "FENG_LIMIT_B": 20}) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+
+	// Test case 2: A non-numeric value names the key
+	t.Setenv("FENG_LIMIT_C", "many")
+	_, err = feng.GetenvMapInt("FENG_LIMIT_")
+	if err == nil || !strings.Contains(err.Error(), "FENG_LIMIT_C") {
+		t.Errorf("Expected an error naming FENG_LIMIT_C, got %v", err)
+	}
+}
+
+func TestGetenvMapBoolAndFloat(t *testing.T) {
+	t.Setenv("FENG_FLAG_A", "true")
+	t.Setenv("FENG_FLAG_B", "0")
+	flags, err := feng.GetenvMapBool("FENG_FLAG_")
+	if err != nil {
+		t.Fatalf("GetenvMapBool returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(flags, map[string]bool{"FENG_FLAG_A": true, "FENG_FLAG_B": false}) {
+		t.Errorf("Unexpected result: %v", flags)
+	}
+
+	t.Setenv("FENG_WEIGHT_A", "0.5")
+	weights, err := feng.GetenvMapFloat64("FENG_WEIGHT_")
+	if err != nil {
+		t.Fatalf("GetenvMapFloat64 returned an error: %v", err)
+	}
+	if weights["FENG_WEIGHT_A"] != 0.5 {
+		t.Errorf("Unexpected result: %v", weights)
+	}
+}
+
+func TestGetenvCSVRecords(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  [][]string
+	}{
+		{"two records", "GET,/health;POST, /submit", [][]string{{"GET", "/health"}, {"POST", "/submit"}}},
+		{"trailing record separator", "GET,/health;", [][]string{{"GET", "/health"}}},
+		{"empty value", "", [][]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_ROUTES", tt.value)
+			got, err := feng.GetenvCSVRecords("FENG_ROUTES", ";", ",")
+			if err != nil {
+				t.Fatalf("GetenvCSVRecords returned an error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, but got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	t.Setenv("FENG_FS_A", "")
+	t.Setenv("FENG_FS_B", "")
+	fsys := fstest.MapFS{
+		"config/base.env":     {Data: []byte("FENG_FS_A=base\nFENG_FS_B=base\n")},
+		"config/override.env": {Data: []byte("# override\nFENG_FS_B=\"override\"\n")},
+	}
+
+	// Test case 1: Reading a single file
+	got, err := feng.ReadEnvFS(fsys, "config/base.env")
+	if err != nil {
+		t.Fatalf("ReadEnvFS returned an error: %v", err)
+	}
+	if !compareMap(got, map[string]string{"FENG_FS_A": "base", "FENG_FS_B": "base"}) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+
+	// Test case 2: Later files override earlier ones
+	if err := feng.LoadFS(fsys, "config/base.env", "config/override.env"); err != nil {
+		t.Fatalf("LoadFS returned an error: %v", err)
+	}
+	if os.Getenv("FENG_FS_A") != "base" || os.Getenv("FENG_FS_B") != "override" {
+		t.Errorf("Unexpected environment: A=%s B=%s", os.Getenv("FENG_FS_A"), os.Getenv("FENG_FS_B"))
+	}
+
+	// Test case 3: Missing file
+	if err := feng.LoadFS(fsys, "config/missing.env"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestGetenvFirst(t *testing.T) {
+	keys := []string{"FENG_DATABASE_URL", "FENG_DB_URL", "FENG_POSTGRES_URL"}
+	for _, k := range keys {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+
+	// Test case 1: None are set
+	if _, _, ok := feng.GetenvFirst(keys...); ok {
+		t.Error("Expected ok=false when no alias is set")
+	}
+
+	// Test case 2: A later alias wins
+	os.Setenv("FENG_POSTGRES_URL", "postgres://later")
+	value, found, ok := feng.GetenvFirst(keys...)
+	if !ok || found != "FENG_POSTGRES_URL" || value != "postgres://later" {
+		t.Errorf("Unexpected result: %s %s %v", value, found, ok)
+	}
+
+	// Test case 3: The first alias wins
+	os.Setenv("FENG_DATABASE_URL", "postgres://first")
+	value, found, ok = feng.GetenvFirst(keys...)
+	if !ok || found != "FENG_DATABASE_URL" || value != "postgres://first" {
+		t.Errorf("Unexpected result: %s %s %v", value, found, ok)
+	}
+}
+
+func TestGetenvColor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{"#f80", color.RGBA{R: 0xff, G: 0x88, B: 0x00, A: 0xff}, false},
+		{"ff8800", color.RGBA{R: 0xff, G: 0x88, B: 0x00, A: 0xff}, false},
+		{"#ff880080", color.RGBA{R: 0xff, G: 0x88, B: 0x00, A: 0x80}, false},
+		{"FF8800", color.RGBA{R: 0xff, G: 0x88, B: 0x00, A: 0xff}, false},
+		{"#ff88", color.RGBA{}, true},
+		{"#gg8800", color.RGBA{}, true},
+		{"", color.RGBA{}, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_ACCENT_COLOR", tt.value)
+		got, err := feng.GetenvColor("FENG_ACCENT_COLOR")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %v, but got %v", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestMustLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing.env")
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Expected MustLoad to panic")
+		}
+		msg, ok := r.(string)
+		if !ok || !strings.Contains(msg, filename) {
+			t.Errorf("Expected panic message to contain %s, got %v", filename, r)
+		}
+	}()
+	feng.MustLoad(filename)
+}
+
+func TestGetenvWeekdays(t *testing.T) {
+	mwf := []time.Weekday{time.Monday, time.Wednesday, time.Friday}
+	tests := []struct {
+		name    string
+		value   string
+		want    []time.Weekday
+		wantErr bool
+	}{
+		{"short names", "Mon,Wed,Fri", mwf, false},
+		{"long names", "Monday, Wednesday, Friday", mwf, false},
+		{"mixed case", "mON,wednesday,FRI", mwf, false},
+		{"invalid day", "Mon,Funday", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_ACTIVE_DAYS", tt.value)
+			got, err := feng.GetenvWeekdays("FENG_ACTIVE_DAYS")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "Funday") {
+					t.Errorf("Expected an error naming Funday, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetenvWeekdays returned an error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetenvQuantity(t *testing.T) {
+	tests := []struct {
+		value     string
+		magnitude float64
+		suffix    string
+		wantErr   bool
+	}{
+		{"512Mi", 512, "Mi", false},
+		{"250m", 250, "m", false},
+		{"1.5G", 1.5, "G", false},
+		{"2", 2, "", false},
+		{"10Xi", 0, "", true},
+		{"Mi", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_MEMORY", tt.value)
+		magnitude, suffix, err := feng.GetenvQuantity("FENG_MEMORY")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if magnitude != tt.magnitude || suffix != tt.suffix {
+			t.Errorf("%q: expected %v %q, but got %v %q", tt.value, tt.magnitude, tt.suffix, magnitude, suffix)
+		}
+	}
+}
+
+func TestGetenvMapWithDefaults(t *testing.T) {
+	t.Setenv("FENG_DEF_HOST", "db.internal")
+	t.Setenv("FENG_DEF_USER", "admin")
+	defaults := map[string]string{
+		"FENG_DEF_HOST": "localhost",
+		"FENG_DEF_PORT": "5432",
+	}
+
+	got := feng.GetenvMapWithDefaults("FENG_DEF_", defaults)
+	expected := map[string]string{
+		"FENG_DEF_HOST": "db.internal", // overridden by the environment
+		"FENG_DEF_PORT": "5432",        // retained from defaults
+		"FENG_DEF_USER": "admin",       // only in the environment
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+	if defaults["FENG_DEF_HOST"] != "localhost" {
+		t.Error("Expected defaults to be left unmodified")
+	}
+}
+
+func TestGetenvSet(t *testing.T) {
+	// Test case 1: Duplicates collapse and entries are trimmed
+	t.Setenv("FENG_ENABLED_FEATURES", "search, beta ,search,,export")
+	set := feng.GetenvSet("FENG_ENABLED_FEATURES", ",")
+	if len(set) != 3 {
+		t.Errorf("Expected 3 entries, but got %d: %v", len(set), set)
+	}
+	for _, item := range []string{"search", "beta", "export"} {
+		if !feng.Contains(set, item) {
+			t.Errorf("Expected set to contain %q", item)
+		}
+	}
+	if feng.Contains(set, "alpha") {
+		t.Error("Expected set not to contain alpha")
+	}
+
+	// Test case 2: Empty value
+	t.Setenv("FENG_ENABLED_FEATURES", "")
+	if set := feng.GetenvSet("FENG_ENABLED_FEATURES", ","); len(set) != 0 {
+		t.Errorf("Expected an empty set, but got %v", set)
+	}
+}
+
+func TestGetenvFileContents(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	if err := os.WriteFile(certFile, []byte("CERTIFICATE\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// Test case 1: Readable file
+	t.Setenv("FENG_TLS_CERT_FILE", certFile)
+	data, err := feng.GetenvFileContents("FENG_TLS_CERT_FILE")
+	if err != nil {
+		t.Fatalf("GetenvFileContents returned an error: %v", err)
+	}
+	if string(data) != "CERTIFICATE\n" {
+		t.Errorf("Unexpected contents: %q", data)
+	}
+
+	// Test case 2: Missing file
+	t.Setenv("FENG_MISSING_FILE", filepath.Join(dir, "missing"))
+	if _, err := feng.GetenvFileContents("FENG_MISSING_FILE"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	// Test case 3: Unset key
+	if _, err := feng.GetenvFileContents("FENG_UNSET_FILE"); err == nil {
+		t.Error("Expected an error for an unset key")
+	}
+}
+
+func TestGetenvStringOrFile(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	t.Setenv("FENG_DB_PASSWORD_FILE", secretFile)
+
+	// Test case 1: Falls back to the _FILE variable
+	got, err := feng.GetenvStringOrFile("FENG_DB_PASSWORD")
+	if err != nil || got != "from-file" {
+		t.Errorf("Expected from-file, got %q (%v)", got, err)
+	}
+
+	// Test case 2: The plain variable is preferred
+	t.Setenv("FENG_DB_PASSWORD", "from-env")
+	got, err = feng.GetenvStringOrFile("FENG_DB_PASSWORD")
+	if err != nil || got != "from-env" {
+		t.Errorf("Expected from-env, got %q (%v)", got, err)
+	}
+
+	// Test case 3: Neither is set
+	if _, err := feng.GetenvStringOrFile("FENG_NOTHING"); err == nil {
+		t.Error("Expected an error when neither variable is set")
+	}
+}
+
+// testLevel is a custom type used to exercise GetenvMapTyped.
+type testLevel struct {
+	Name string
+}
+
+func TestGetenvMapTyped(t *testing.T) {
+	// Test case 1: Parsing into ints
+	t.Setenv("FENG_TYPED_A", "1")
+	t.Setenv("FENG_TYPED_B", "2")
+	ints, err := feng.GetenvMapTyped("FENG_TYPED_", strconv.Atoi)
+	if err != nil {
+		t.Fatalf("GetenvMapTyped returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(ints, map[string]int{"FENG_TYPED_A": 1, "FENG_TYPED_B": 2}) {
+		t.Errorf("Unexpected result: %v", ints)
+	}
+
+	// Test case 2: Parsing into a custom type
+	levels, err := feng.GetenvMapTyped("FENG_TYPED_", func(s string) (testLevel, error) {
+		return testLevel{Name: "level-" + s}, nil
+	})
+	if err != nil {
+		t.Fatalf("GetenvMapTyped returned an error: %v", err)
+	}
+	if levels["FENG_TYPED_B"].Name != "level-2" {
+		t.Errorf("Unexpected result: %v", levels)
+	}
+
+	// Test case 3: The first failing key is reported
+	t.Setenv("FENG_TYPED_C", "x")
+	t.Setenv("FENG_TYPED_D", "y")
+	_, err = feng.GetenvMapTyped("FENG_TYPED_", strconv.Atoi)
+	if err == nil || !strings.Contains(err.Error(), "FENG_TYPED_C") {
+		t.Errorf("Expected an error naming FENG_TYPED_C, got %v", err)
+	}
+}
+
+func TestIntegerGettersInferBase(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"0xFF", 255, false},
+		{"0o022", 18, false},
+		{"0b101", 5, false},
+		{"42", 42, false},
+		{"0755", 493, false},
+		{"1_000", 1000, false},
+		{"09", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_BASE", tt.value)
+		got, err := feng.GetenvInt("FENG_BASE")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %d, but got %d", tt.value, tt.want, got)
+		}
+	}
+
+	// Test case: Other widths share the same base rules
+	t.Setenv("FENG_BASE", "0xFF")
+	if v, err := feng.GetenvUint8("FENG_BASE"); err != nil || v != 255 {
+		t.Errorf("GetenvUint8: expected 255, got %d (%v)", v, err)
+	}
+	if _, err := feng.GetenvInt8("FENG_BASE"); err == nil {
+		t.Error("GetenvInt8: expected an out of range error for 0xFF")
+	}
+	if v, err := feng.GetenvInt64("FENG_BASE"); err != nil || v != 255 {
+		t.Errorf("GetenvInt64: expected 255, got %d (%v)", v, err)
+	}
+}
+
+func TestGetenvCommaInt(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"1,000,000", 1000000, false},
+		{"-12,345", -12345, false},
+		{"1000000", 1000000, false},
+		{"1,00,000", 0, true},
+		{",100", 0, true},
+		{"1,000,", 0, true},
+		{"1,0a0", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_MAX_ROWS", tt.value)
+		got, err := feng.GetenvCommaInt("FENG_MAX_ROWS")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %d, but got %d", tt.value, tt.want, got)
+		}
+	}
+
+	// Test case: A custom separator
+	t.Setenv("FENG_MAX_ROWS", "2.500")
+	if got, err := feng.GetenvGroupedInt("FENG_MAX_ROWS", "."); err != nil || got != 2500 {
+		t.Errorf("Expected 2500, got %d (%v)", got, err)
+	}
+}
+
+func TestGetenvEnabledDisabled(t *testing.T) {
+	tests := []struct {
+		name           string
+		value          string
+		set            bool
+		defaultEnabled bool
+		enabled        bool // expected GetenvEnabled result
+		notDisabled    bool // expected GetenvDisabled result
+	}{
+		{"set true, default on", "true", true, true, true, false},
+		{"set true, default off", "true", true, false, true, false},
+		{"set false, default on", "false", true, true, false, true},
+		{"set false, default off", "false", true, false, false, true},
+		{"unset, default on", "", false, true, true, true},
+		{"unset, default off", "", false, false, false, false},
+		{"set on, default off", "on", true, false, true, false},
+		{"set off, default on", "off", true, true, false, true},
+		{"set Yes, default off", "Yes", true, false, true, false},
+		{"unparseable, default on", "maybe", true, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_FEATURE_FLAG", tt.value)
+			if !tt.set {
+				os.Unsetenv("FENG_FEATURE_FLAG")
+			}
+			if got := feng.GetenvEnabled("FENG_FEATURE_FLAG", tt.defaultEnabled); got != tt.enabled {
+				t.Errorf("GetenvEnabled: expected %v, but got %v", tt.enabled, got)
+			}
+			if got := feng.GetenvDisabled("FENG_FEATURE_FLAG", tt.defaultEnabled); got != tt.notDisabled {
+				t.Errorf("GetenvDisabled: expected %v, but got %v", tt.notDisabled, got)
+			}
+		})
+	}
+}
+
+func TestGetenvValues(t *testing.T) {
+	t.Setenv("FENG_ENDPOINT_2", "b.example.com")
+	t.Setenv("FENG_ENDPOINT_10", "c.example.com")
+	t.Setenv("FENG_ENDPOINT_1", "a.example.com")
+
+	// Lexical ordering: _1 < _10 < _2
+	want := []string{"a.example.com", "c.example.com", "b.example.com"}
+	for i := 0; i < 3; i++ {
+		if got := feng.GetenvValues("FENG_ENDPOINT_"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, but got %v", want, got)
+		}
+	}
+}
+
+func TestGetenvDurationClamped(t *testing.T) {
+	min, max := time.Second, time.Minute
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"below min", "-5s", time.Second},
+		{"above max", "2h", time.Minute},
+		{"in range", "30s", 30 * time.Second},
+		{"explicit plus sign", "+10s", 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_TIMEOUT", tt.value)
+			got, err := feng.GetenvDurationClamped("FENG_TIMEOUT", min, max)
+			if err != nil {
+				t.Fatalf("GetenvDurationClamped returned an error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %v, but got %v", tt.want, got)
+			}
+		})
+	}
+
+	// Test case: Erroring instead of clamping
+	t.Setenv("FENG_TIMEOUT", "-5s")
+	if _, err := feng.GetenvDurationBounded("FENG_TIMEOUT", min, max, false); err == nil {
+		t.Error("Expected an error for an out-of-range value")
+	}
+	t.Setenv("FENG_TIMEOUT", "5s")
+	if got, err := feng.GetenvDurationBounded("FENG_TIMEOUT", min, max, false); err != nil || got != 5*time.Second {
+		t.Errorf("Expected 5s, got %v (%v)", got, err)
+	}
+}
+
+func TestGetenvMapGroups(t *testing.T) {
+	t.Setenv("FENGDB_PRIMARY_HOST", "10.0.0.1")
+	t.Setenv("FENGDB_PRIMARY_PORT", "5432")
+	t.Setenv("FENGDB_REPLICA_HOST", "10.0.0.2")
+	t.Setenv("FENGDB_REPLICA_MAX_LAG", "5s")
+	t.Setenv("FENGDB_TIMEOUT", "30s")
+
+	got := feng.GetenvMapGroups("FENGDB_", "_")
+	expected := map[string]map[string]string{
+		"PRIMARY": {"HOST": "10.0.0.1", "PORT": "5432"},
+		"REPLICA": {"HOST": "10.0.0.2", "MAX_LAG": "5s"},
+		"":        {"TIMEOUT": "30s"},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
+
+func TestGetenvMillisAndSeconds(t *testing.T) {
+	// Test case 1: Integer milliseconds
+	t.Setenv("FENG_TIMEOUT_MS", "1500")
+	if got, err := feng.GetenvMillis("FENG_TIMEOUT_MS"); err != nil || got != 1500*time.Millisecond {
+		t.Errorf("Expected 1.5s, got %v (%v)", got, err)
+	}
+
+	// Test case 2: Integer and fractional seconds
+	t.Setenv("FENG_TTL_SECONDS", "30")
+	if got, err := feng.GetenvSeconds("FENG_TTL_SECONDS"); err != nil || got != 30*time.Second {
+		t.Errorf("Expected 30s, got %v (%v)", got, err)
+	}
+	t.Setenv("FENG_TTL_SECONDS", "1.5")
+	if got, err := feng.GetenvSeconds("FENG_TTL_SECONDS"); err != nil || got != 1500*time.Millisecond {
+		t.Errorf("Expected 1.5s, got %v (%v)", got, err)
+	}
+
+	// Test case 3: Invalid values
+	t.Setenv("FENG_TIMEOUT_MS", "1.5s")
+	if _, err := feng.GetenvMillis("FENG_TIMEOUT_MS"); err == nil {
+		t.Error("Expected an error for an invalid millisecond value")
+	}
+	t.Setenv("FENG_TTL_SECONDS", "thirty")
+	if _, err := feng.GetenvSeconds("FENG_TTL_SECONDS"); err == nil {
+		t.Error("Expected an error for an invalid second value")
+	}
+
+	// Test case 4: Non-finite and out of range values
+	for _, value := range []string{"NaN", "Inf", "-inf"} {
+		t.Setenv("FENG_TTL_SECONDS", value)
+		if _, err := feng.GetenvSeconds("FENG_TTL_SECONDS"); err == nil || !strings.Contains(err.Error(), "finite") {
+			t.Errorf("%q: expected a non-finite error, got %v", value, err)
+		}
+	}
+	for _, value := range []string{"9223372037", "-9223372037", "1e300"} {
+		t.Setenv("FENG_TTL_SECONDS", value)
+		if _, err := feng.GetenvSeconds("FENG_TTL_SECONDS"); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("%q: expected an out of range error, got %v", value, err)
+		}
+	}
+	t.Setenv("FENG_TTL_SECONDS", "9223372036")
+	if got, err := feng.GetenvSeconds("FENG_TTL_SECONDS"); err != nil || got != 9223372036*time.Second {
+		t.Errorf("Expected 9223372036s, got %v (%v)", got, err)
+	}
+	for _, value := range []string{"9223372036855", "-9223372036855"} {
+		t.Setenv("FENG_TIMEOUT_MS", value)
+		if _, err := feng.GetenvMillis("FENG_TIMEOUT_MS"); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("%q: expected an out of range error, got %v", value, err)
+		}
+	}
+	t.Setenv("FENG_TIMEOUT_MS", "9223372036854")
+	if got, err := feng.GetenvMillis("FENG_TIMEOUT_MS"); err != nil || got != 9223372036854*time.Millisecond {
+		t.Errorf("Expected 9223372036854ms, got %v (%v)", got, err)
+	}
+}
+
+func TestGetenvRatio(t *testing.T) {
+	tests := []struct {
+		value      string
+		num, denom int
+		wantErr    bool
+	}{
+		{"16:9", 16, 9, false},
+		{"4:3", 4, 3, false},
+		{"1:0", 0, 0, true},
+		{"16", 0, 0, true},
+		{"16:", 0, 0, true},
+		{"a:b", 0, 0, true},
+		{"1.5:1", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_ASPECT", tt.value)
+		num, denom, err := feng.GetenvRatio("FENG_ASPECT")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if num != tt.num || denom != tt.denom {
+			t.Errorf("%q: expected %d:%d, but got %d:%d", tt.value, tt.num, tt.denom, num, denom)
+		}
+	}
+}
+
+func TestGetenvEmail(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"ops@example.com", "ops@example.com", false},
+		{"Ops <ops@example.com>", "ops@example.com", false},
+		{"not-an-address", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_ALERT_EMAIL", tt.value)
+		got, err := feng.GetenvEmail("FENG_ALERT_EMAIL")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %s, but got %s", tt.value, tt.want, got)
+		}
+	}
+
+	// Test case: A list of addresses
+	t.Setenv("FENG_ALERT_EMAILS", "ops@example.com, Dev Team <dev@example.com>")
+	got, err := feng.GetenvEmails("FENG_ALERT_EMAILS")
+	if err != nil {
+		t.Fatalf("GetenvEmails returned an error: %v", err)
+	}
+	if want := []string{"ops@example.com", "dev@example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, but got %v", want, got)
+	}
+}
+
+func TestGetenvProbability(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"1", 1, false},
+		{"0.05", 0.05, false},
+		{"1.5", 0, true},
+		{"-0.1", 0, true},
+		{"NaN", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_TRACE_SAMPLE", tt.value)
+		got, err := feng.GetenvProbability("FENG_TRACE_SAMPLE")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %v, but got %v", tt.value, tt.want, got)
+		}
+	}
+
+	// Test case: Defaults are validated too
+	t.Setenv("FENG_TRACE_SAMPLE", "")
+	if got, err := feng.GetenvProbabilityOrDefault("FENG_TRACE_SAMPLE", 0.1); err != nil || got != 0.1 {
+		t.Errorf("Expected default 0.1, got %v (%v)", got, err)
+	}
+	if _, err := feng.GetenvProbabilityOrDefault("FENG_TRACE_SAMPLE", 2); err == nil {
+		t.Error("Expected an error for an out-of-range default")
+	}
+}
+
+func TestGetenvMapValueWithEquals(t *testing.T) {
+	t.Setenv("FENG_EQ_DSN", "host=db user=app")
+	got := feng.GetenvMap("FENG_EQ_")
+	if got["FENG_EQ_DSN"] != "host=db user=app" {
+		t.Errorf("Expected the full value, but got %q", got["FENG_EQ_DSN"])
+	}
+}
+
+// getenvMapSplit is the previous GetenvMap implementation, kept as a baseline for
+// BenchmarkGetenvMap.
+func getenvMapSplit(prefix string) map[string]string {
+	envMap := make(map[string]string)
+	for _, v := range os.Environ() {
+		envLine := strings.Split(v, "=")
+		k := envLine[0]
+		v := envLine[1]
+		if strings.HasPrefix(k, prefix) || prefix == "" {
+			envMap[k] = v
+		}
+	}
+	return envMap
+}
+
+func BenchmarkGetenvMap(b *testing.B) {
+	b.Run("split", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			getenvMapSplit("")
+		}
+	})
+	b.Run("indexbyte", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			feng.GetenvMap("")
+		}
+	})
+}
+
+func TestGetenvStringSliceDefaults(t *testing.T) {
+	defaults := []string{"10", "20"}
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"all provided", "1,2", []string{"1", "2"}},
+		{"some provided", "1", []string{"1", "20"}},
+		{"empty element", ",2", []string{"10", "2"}},
+		{"none provided", "", []string{"10", "20"}},
+		{"extra elements", "1,2,3", []string{"1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_RANGE", tt.value)
+			got := feng.GetenvStringSliceDefaults("FENG_RANGE", ",", defaults)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, but got %v", tt.want, got)
+			}
+		})
+	}
+	if !reflect.DeepEqual(defaults, []string{"10", "20"}) {
+		t.Error("Expected defaults to be left unmodified")
+	}
+}
+
+func TestGetenvTristate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    bool
+		set     bool
+		wantErr bool
+	}{
+		{"set true", "true", true, true, false},
+		{"set yes", "YES", true, true, false},
+		{"set on", "On", true, true, false},
+		{"set false", "false", false, true, false},
+		{"set off", "off", false, true, false},
+		{"set disabled", "Disabled", false, true, false},
+		{"unset", "", false, false, false},
+		{"invalid", "maybe", false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_TRISTATE", tt.value)
+			value, set, err := feng.GetenvTristate("FENG_TRISTATE")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error state: %v", err)
+			}
+			if value != tt.want || set != tt.set {
+				t.Errorf("Expected (%v, %v), but got (%v, %v)", tt.want, tt.set, value, set)
+			}
+		})
+	}
+}
+
+func TestGetenvVerifiedBytes(t *testing.T) {
+	// sha256("hello world")
+	const sum = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+	// Test case 1: Matching raw and base64 values
+	t.Run("Match", func(t *testing.T) {
+		for _, value := range []string{"hello world", "base64:aGVsbG8gd29ybGQ="} {
+			t.Setenv("FENG_BLOB", value)
+			t.Setenv("FENG_BLOB_SHA256", strings.ToUpper(sum))
+			got, err := feng.GetenvVerifiedBytes("FENG_BLOB", "FENG_BLOB_SHA256")
+			if err != nil {
+				t.Fatalf("GetenvVerifiedBytes returned an error: %v", err)
+			}
+			if string(got) != "hello world" {
+				t.Errorf("Expected %q, but got %q", "hello world", got)
+			}
+		}
+	})
+
+	// Test case 2: A tampered value
+	t.Run("Mismatch", func(t *testing.T) {
+		t.Setenv("FENG_BLOB", "hello world!")
+		t.Setenv("FENG_BLOB_SHA256", sum)
+		got, err := feng.GetenvVerifiedBytes("FENG_BLOB", "FENG_BLOB_SHA256")
+		if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Errorf("Expected a checksum mismatch error, got %v", err)
+		}
+		if got != nil {
+			t.Errorf("Expected no bytes, but got %q", got)
+		}
+	})
+
+	// Test case 3: The sum variable is missing
+	t.Run("Missing sum", func(t *testing.T) {
+		t.Setenv("FENG_BLOB", "hello world")
+		os.Unsetenv("FENG_BLOB_SHA256")
+		if _, err := feng.GetenvVerifiedBytes("FENG_BLOB", "FENG_BLOB_SHA256"); err == nil || !strings.Contains(err.Error(), "FENG_BLOB_SHA256 not set") {
+			t.Errorf("Expected a not set error, got %v", err)
+		}
+	})
+}
+
+func TestGetenvDecimal(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantUnits int64
+		wantScale int
+		wantErr   bool
+	}{
+		{"42", 42, 0, false},
+		{"19.99", 1999, 2, false},
+		{"19.90", 1990, 2, false},
+		{"-0.05", -5, 2, false},
+		{"+3.0", 30, 1, false},
+		{"1.2.3", 0, 0, true},
+		{"19.", 0, 0, true},
+		{".5", 0, 0, true},
+		{"1e3", 0, 0, true},
+		{"--1", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_PRICE", tt.value)
+		units, scale, err := feng.GetenvDecimal("FENG_PRICE")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if units != tt.wantUnits || scale != tt.wantScale {
+			t.Errorf("%q: expected (%d, %d), but got (%d, %d)", tt.value, tt.wantUnits, tt.wantScale, units, scale)
+		}
+	}
+}
+
+func TestGetenvMapExclude(t *testing.T) {
+	t.Setenv("FENG_EX_KEEP", "1")
+	t.Setenv("FENG_EX_SECRET_A", "2")
+	t.Setenv("FENG_EX_TOKEN", "3")
+
+	// Test case 1: A single excluded prefix
+	got := feng.GetenvMapExclude("FENG_EX_SECRET_")
+	if got["FENG_EX_KEEP"] != "1" || got["FENG_EX_TOKEN"] != "3" {
+		t.Errorf("Expected non-excluded variables to be kept, got %v", got)
+	}
+	if _, ok := got["FENG_EX_SECRET_A"]; ok {
+		t.Error("Expected FENG_EX_SECRET_A to be excluded")
+	}
+
+	// Test case 2: Multiple excluded prefixes
+	got = feng.GetenvMapExclude("FENG_EX_SECRET_", "FENG_EX_TOKEN", "PATH")
+	for _, k := range []string{"FENG_EX_SECRET_A", "FENG_EX_TOKEN", "PATH"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("Expected %s to be excluded", k)
+		}
+	}
+	if got["FENG_EX_KEEP"] != "1" {
+		t.Errorf("Expected FENG_EX_KEEP to be kept, got %v", got)
+	}
+}
+
+func TestGetenvRetryPolicy(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantAttempts int
+		wantBackoff  time.Duration
+		wantErr      string
+	}{
+		{"3x500ms", 3, 500 * time.Millisecond, ""},
+		{"1x1s", 1, time.Second, ""},
+		{"5x2s", 5, 2 * time.Second, ""},
+		{"5 2s", 0, 0, "expected <attempts>x<backoff>"},
+		{"x2s", 0, 0, "attempts"},
+		{"fivex2s", 0, 0, "attempts"},
+		{"0x2s", 0, 0, "attempts"},
+		{"5x", 0, 0, "backoff"},
+		{"5xsoon", 0, 0, "backoff"},
+		{"", 0, 0, "not set"},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_RETRY", tt.value)
+		attempts, backoff, err := feng.GetenvRetryPolicy("FENG_RETRY")
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: expected an error containing %q, got %v", tt.value, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: GetenvRetryPolicy returned an error: %v", tt.value, err)
+			continue
+		}
+		if attempts != tt.wantAttempts || backoff != tt.wantBackoff {
+			t.Errorf("%q: expected (%d, %s), but got (%d, %s)", tt.value, tt.wantAttempts, tt.wantBackoff, attempts, backoff)
+		}
+	}
+}
+
+func TestGetenvBitSize(t *testing.T) {
+	// Test case 1: A permitted size
+	t.Setenv("FENG_RSA_BITS", "2048")
+	if got, err := feng.GetenvBitSize("FENG_RSA_BITS", 2048, 3072, 4096); err != nil || got != 2048 {
+		t.Errorf("Expected 2048, but got %d (%v)", got, err)
+	}
+
+	// Test case 2: A disallowed size lists the allowed ones
+	t.Setenv("FENG_RSA_BITS", "1024")
+	_, err := feng.GetenvBitSize("FENG_RSA_BITS", 2048, 3072, 4096)
+	if err == nil || !strings.Contains(err.Error(), "allowed sizes are 2048, 3072, 4096") {
+		t.Errorf("Expected an error listing the allowed sizes, got %v", err)
+	}
+
+	// Test case 3: An unrestricted call
+	if got, err := feng.GetenvBitSize("FENG_RSA_BITS"); err != nil || got != 1024 {
+		t.Errorf("Expected 1024, but got %d (%v)", got, err)
+	}
+
+	// Test case 4: Invalid values
+	for _, value := range []string{"", "big", "0", "-64"} {
+		t.Setenv("FENG_RSA_BITS", value)
+		if _, err := feng.GetenvBitSize("FENG_RSA_BITS"); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestFlattenJSON(t *testing.T) {
+	data := []byte(`{
+		"db": {"host": "localhost", "port": 5432, "replica": {"enabled": true}},
+		"hosts": ["a", "b"],
+		"ratio": 0.25,
+		"big": 12345678901234567890,
+		"note": null,
+		"empty": {},
+		"matrix": [[1, 2], [{"x": "y"}]]
+	}`)
+
+	// Test case 1: Nested objects, arrays and mixed scalars
+	got, err := feng.FlattenJSON(data, "APP_", "_")
+	if err != nil {
+		t.Fatalf("FlattenJSON returned an error: %v", err)
+	}
+	expected := map[string]string{
+		"APP_db_host":            "localhost",
+		"APP_db_port":            "5432",
+		"APP_db_replica_enabled": "true",
+		"APP_hosts_0":            "a",
+		"APP_hosts_1":            "b",
+		"APP_ratio":              "0.25",
+		"APP_big":                "12345678901234567890",
+		"APP_note":               "",
+		"APP_matrix_0_0":         "1",
+		"APP_matrix_0_1":         "2",
+		"APP_matrix_1_0_x":       "y",
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	// Test case 2: A custom separator without prefix
+	got, err = feng.FlattenJSON([]byte(`{"a":{"b":"c"}}`), "", "__")
+	if err != nil {
+		t.Fatalf("FlattenJSON returned an error: %v", err)
+	}
+	if !compareMap(map[string]string{"a__b": "c"}, got) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+
+	// Test case 3: Input that is not an object
+	for _, input := range []string{`[1,2]`, `"str"`, `{bad`} {
+		if _, err := feng.FlattenJSON([]byte(input), "", "_"); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+
+	// Test case 4: Uppercased keys keep the prefix as given
+	got, err = feng.FlattenJSON([]byte(`{"db":{"Host":"x","ports":[1]}}`), "app_", "_", feng.WithUpperKeys(true))
+	if err != nil {
+		t.Fatalf("FlattenJSON returned an error: %v", err)
+	}
+	if !compareMap(map[string]string{"app_DB_HOST": "x", "app_DB_PORTS_0": "1"}, got) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+
+	// Test case 5: Keys that collide once uppercased
+	_, err = feng.FlattenJSON([]byte(`{"db":{"host":"x"},"DB":{"HOST":"y"}}`), "", "_", feng.WithUpperKeys(true))
+	if err == nil || !strings.Contains(err.Error(), "JSON paths DB.HOST and db.host both flatten to DB_HOST") {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+
+	// Test case 6: Different paths joined into the same key, with or without uppercasing
+	for _, opts := range [][]feng.FlattenOption{nil, {feng.WithUpperKeys(true)}} {
+		_, err = feng.FlattenJSON([]byte(`{"a_b":"1","a":{"b":"2"},"c":[{"d":1}],"c_0_d":2}`), "", "_", opts...)
+		if err == nil || !strings.Contains(err.Error(), "JSON paths a.b and a_b both flatten to") {
+			t.Errorf("Expected a collision error, got %v", err)
+		}
+	}
+	_, err = feng.FlattenJSON([]byte(`{"c":[{"d":1}],"c_0_d":2}`), "", "_")
+	if err == nil || !strings.Contains(err.Error(), "JSON paths c[0].d and c_0_d both flatten to c_0_d") {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+}
+
+func TestGetenvValidated(t *testing.T) {
+	isHostPort := func(s string) error {
+		if !strings.Contains(s, ":") {
+			return errors.New("expected host:port")
+		}
+		return nil
+	}
+
+	// Test case 1: A passing validator
+	t.Setenv("FENG_UPSTREAM", "db:5432")
+	if got, err := feng.GetenvValidated("FENG_UPSTREAM", isHostPort); err != nil || got != "db:5432" {
+		t.Errorf("Expected db:5432, but got %q (%v)", got, err)
+	}
+
+	// Test case 2: A failing validator
+	t.Setenv("FENG_UPSTREAM", "db")
+	_, err := feng.GetenvValidated("FENG_UPSTREAM", isHostPort)
+	if err == nil || !strings.Contains(err.Error(), "FENG_UPSTREAM") || !strings.Contains(err.Error(), "expected host:port") {
+		t.Errorf("Expected the validator error prefixed with the key, got %v", err)
+	}
+
+	// Test case 3: An unset key
+	os.Unsetenv("FENG_UPSTREAM")
+	if _, err := feng.GetenvValidated("FENG_UPSTREAM", isHostPort); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("Expected a not set error, got %v", err)
+	}
+}
+
+func TestCountSet(t *testing.T) {
+	keys := []string{"FENG_CRED_A", "FENG_CRED_B", "FENG_CRED_C"}
+	for _, k := range keys {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+
+	// Test case 1: No key set
+	if got := feng.CountSet(keys...); got != 0 {
+		t.Errorf("Expected 0, but got %d", got)
+	}
+	if err := feng.ExactlyOneSet(keys...); err == nil || !strings.Contains(err.Error(), "none") {
+		t.Errorf("Expected a none set error, got %v", err)
+	}
+
+	// Test case 2: One key set, an empty one is not counted
+	t.Setenv("FENG_CRED_A", "token")
+	t.Setenv("FENG_CRED_B", "")
+	if got := feng.CountSet(keys...); got != 1 {
+		t.Errorf("Expected 1, but got %d", got)
+	}
+	if err := feng.ExactlyOneSet(keys...); err != nil {
+		t.Errorf("ExactlyOneSet returned an error: %v", err)
+	}
+
+	// Test case 3: Multiple keys set
+	t.Setenv("FENG_CRED_C", "key")
+	if got := feng.CountSet(keys...); got != 2 {
+		t.Errorf("Expected 2, but got %d", got)
+	}
+	if err := feng.ExactlyOneSet(keys...); err == nil || !strings.Contains(err.Error(), "FENG_CRED_A, FENG_CRED_C are") {
+		t.Errorf("Expected an error naming the set keys, got %v", err)
+	}
+}
+
+func TestGetenvMapApply(t *testing.T) {
+	t.Setenv("FENG_APPLY_A", "  MiXed ")
+	t.Setenv("FENG_APPLY_B", "UPPER")
+
+	// Test case 1: A transform that succeeds for every key
+	got, err := feng.GetenvMapApply("FENG_APPLY_", func(key, value string) (string, error) {
+		return strings.ToLower(strings.TrimSpace(value)), nil
+	})
+	if err != nil {
+		t.Fatalf("GetenvMapApply returned an error: %v", err)
+	}
+	expected := map[string]string{"FENG_APPLY_A": "mixed", "FENG_APPLY_B": "upper"}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	// Test case 2: A transform failing for a specific key
+	got, err = feng.GetenvMapApply("FENG_APPLY_", func(key, value string) (string, error) {
+		if key == "FENG_APPLY_B" {
+			return "", errors.New("rejected")
+		}
+		return value, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "FENG_APPLY_B") || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("Expected an error naming FENG_APPLY_B, got %v", err)
+	}
+	if got != nil {
+		t.Errorf("Expected a nil map on error, but got %v", got)
+	}
+}
+
+func TestGetenvHostPort(t *testing.T) {
+	tests := []struct {
+		value    string
+		wantHost string
+		wantPort int
+		wantErr  bool
+	}{
+		{"0.0.0.0:8080", "0.0.0.0", 8080, false},
+		{"[::1]:443", "::1", 443, false},
+		{":9000", "", 9000, false},
+		{"localhost", "", 0, true},
+		{"::1:443", "", 0, true},
+		{"localhost:http", "", 0, true},
+		{"localhost:70000", "", 0, true},
+		{"", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_LISTEN", tt.value)
+		host, port, err := feng.GetenvHostPort("FENG_LISTEN")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if host != tt.wantHost || port != tt.wantPort {
+			t.Errorf("%q: expected (%q, %d), but got (%q, %d)", tt.value, tt.wantHost, tt.wantPort, host, port)
+		}
+	}
+}
+
+func TestRequireIf(t *testing.T) {
+	t.Setenv("FENG_TLS_CERT", "")
+	t.Setenv("FENG_TLS_KEY", "")
+	os.Unsetenv("FENG_TLS_KEY")
+
+	// Test case 1: Condition true with missing keys
+	t.Setenv("FENG_TLS_ENABLED", "yes")
+	err := feng.RequireIf("FENG_TLS_ENABLED", "FENG_TLS_CERT", "FENG_TLS_KEY")
+	if err == nil || !strings.Contains(err.Error(), "FENG_TLS_CERT, FENG_TLS_KEY") {
+		t.Errorf("Expected an error listing both missing keys, got %v", err)
+	}
+
+	// Test case 2: Condition true with every key present
+	t.Setenv("FENG_TLS_CERT", "cert.pem")
+	t.Setenv("FENG_TLS_KEY", "key.pem")
+	if err := feng.RequireIf("FENG_TLS_ENABLED", "FENG_TLS_CERT", "FENG_TLS_KEY"); err != nil {
+		t.Errorf("RequireIf returned an error: %v", err)
+	}
+
+	// Test case 3: Condition false or unset is a no-op
+	t.Setenv("FENG_TLS_CERT", "")
+	for _, value := range []string{"off", ""} {
+		t.Setenv("FENG_TLS_ENABLED", value)
+		if err := feng.RequireIf("FENG_TLS_ENABLED", "FENG_TLS_CERT"); err != nil {
+			t.Errorf("%q: RequireIf returned an error: %v", value, err)
+		}
+	}
+
+	// Test case 4: An invalid condition value
+	t.Setenv("FENG_TLS_ENABLED", "maybe")
+	if err := feng.RequireIf("FENG_TLS_ENABLED", "FENG_TLS_CERT"); err == nil {
+		t.Error("Expected an error for an invalid condition value")
+	}
+}
+
+func TestGetenvJSONSlice(t *testing.T) {
+	// Test case 1: A slice of ints
+	t.Setenv("FENG_JSON_PORTS", "[80, 443, 8080]")
+	ports, err := feng.GetenvJSONSlice[int]("FENG_JSON_PORTS")
+	if err != nil || !reflect.DeepEqual(ports, []int{80, 443, 8080}) {
+		t.Errorf("Expected [80 443 8080], but got %v (%v)", ports, err)
+	}
+
+	// Test case 2: A slice of strings
+	t.Setenv("FENG_JSON_HOSTS", `["a.example.com", "b,c"]`)
+	hosts, err := feng.GetenvJSONSlice[string]("FENG_JSON_HOSTS")
+	if err != nil || !reflect.DeepEqual(hosts, []string{"a.example.com", "b,c"}) {
+		t.Errorf("Unexpected hosts: %q (%v)", hosts, err)
+	}
+
+	// Test case 3: A slice of structs
+	type upstream struct {
+		Host   string `json:"host"`
+		Weight int    `json:"weight"`
+	}
+	t.Setenv("FENG_JSON_UPSTREAMS", `[{"host":"a","weight":1},{"host":"b","weight":3}]`)
+	upstreams, err := feng.GetenvJSONSlice[upstream]("FENG_JSON_UPSTREAMS")
+	if err != nil || !reflect.DeepEqual(upstreams, []upstream{{"a", 1}, {"b", 3}}) {
+		t.Errorf("Unexpected upstreams: %+v (%v)", upstreams, err)
+	}
+
+	// Test case 4: Unset, invalid and mistyped values
+	for _, value := range []string{"", "[80,", `{"a":1}`, `["80"]`, "null"} {
+		t.Setenv("FENG_JSON_PORTS", value)
+		if _, err := feng.GetenvJSONSlice[int]("FENG_JSON_PORTS"); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestGetenvOrFunc(t *testing.T) {
+	calls := 0
+	fallback := func() (string, error) {
+		calls++
+		return "computed", nil
+	}
+
+	// Test case 1: fn is not called when the variable is set
+	t.Setenv("FENG_LAZY", "from-env")
+	if got, err := feng.GetenvOrFunc("FENG_LAZY", fallback); err != nil || got != "from-env" {
+		t.Errorf("Expected from-env, but got %q (%v)", got, err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected fn not to be called, but it was called %d times", calls)
+	}
+
+	// Test case 2: fn is called once when the variable is unset
+	os.Unsetenv("FENG_LAZY")
+	if got, err := feng.GetenvOrFunc("FENG_LAZY", fallback); err != nil || got != "computed" {
+		t.Errorf("Expected computed, but got %q (%v)", got, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected fn to be called once, but it was called %d times", calls)
+	}
+
+	// Test case 3: An error from fn is propagated
+	wantErr := errors.New("lookup failed")
+	if _, err := feng.GetenvOrFunc("FENG_LAZY", func() (string, error) { return "", wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("Expected %v, but got %v", wantErr, err)
+	}
+}
+
+func TestGetenvIntRange(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantMin int
+		wantMax int
+		wantErr string
+	}{
+		{"3000-3010", 3000, 3010, ""},
+		{" 7 - 7 ", 7, 7, ""},
+		{"-5-5", -5, 5, ""},
+		{"-10--2", -10, -2, ""},
+		{"3010-3000", 0, 0, "greater than"},
+		{"3000", 0, 0, "expected min-max"},
+		{"-5", 0, 0, "expected min-max"},
+		{"3000-", 0, 0, "upper bound"},
+		{"a-10", 0, 0, "lower bound"},
+		{"1-b", 0, 0, "upper bound"},
+		{"", 0, 0, "not set"},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_PORT_RANGE", tt.value)
+		min, max, err := feng.GetenvIntRange("FENG_PORT_RANGE")
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: expected an error containing %q, got %v", tt.value, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || min != tt.wantMin || max != tt.wantMax {
+			t.Errorf("%q: expected (%d, %d), but got (%d, %d, %v)", tt.value, tt.wantMin, tt.wantMax, min, max, err)
+		}
+	}
+}
+
+func TestGetenvMapStrict(t *testing.T) {
+	// Test case 1: A well-formed environment
+	t.Setenv("FENG_STRICT_MAP", "a=b")
+	got, err := feng.GetenvMapStrict("FENG_STRICT_MAP")
+	if err != nil {
+		t.Fatalf("GetenvMapStrict returned an error: %v", err)
+	}
+	if !compareMap(map[string]string{"FENG_STRICT_MAP": "a=b"}, got) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+
+	// Test case 2: Malformed entries are skipped and reported
+	environ := []string{"APP_A=1", "APP_BROKEN", "OTHER=2", "=hidden", "APP_B="}
+	envMap, malformed := feng.ParseEnviron(environ, "APP_")
+	if !compareMap(map[string]string{"APP_A": "1", "APP_B": ""}, envMap) {
+		t.Errorf("Unexpected map: %v", envMap)
+	}
+	if !reflect.DeepEqual(malformed, []string{"APP_BROKEN"}) {
+		t.Errorf("Expected [APP_BROKEN] to be reported, but got %q", malformed)
+	}
+}
+
+func TestAppendToPathVar(t *testing.T) {
+	sep := string(os.PathListSeparator)
+
+	// Test case 1: An initially unset variable
+	t.Setenv("FENG_TEST_PATHVAR", "")
+	os.Unsetenv("FENG_TEST_PATHVAR")
+	if err := feng.AppendToPathVar("FENG_TEST_PATHVAR", "/usr/bin", ""); err != nil {
+		t.Fatalf("AppendToPathVar returned an error: %v", err)
+	}
+	if got := os.Getenv("FENG_TEST_PATHVAR"); got != "/usr/bin" {
+		t.Errorf("Expected /usr/bin, but got %s", got)
+	}
+
+	// Test case 2: Appending and prepending new entries
+	if err := feng.AppendToPathVar("FENG_TEST_PATHVAR", "/opt/bin", ""); err != nil {
+		t.Fatalf("AppendToPathVar returned an error: %v", err)
+	}
+	if err := feng.PrependToPathVar("FENG_TEST_PATHVAR", "/home/me/bin", ""); err != nil {
+		t.Fatalf("PrependToPathVar returned an error: %v", err)
+	}
+	want := strings.Join([]string{"/home/me/bin", "/usr/bin", "/opt/bin"}, sep)
+	if got := os.Getenv("FENG_TEST_PATHVAR"); got != want {
+		t.Errorf("Expected %s, but got %s", want, got)
+	}
+
+	// Test case 3: A duplicate entry is a no-op
+	if err := feng.AppendToPathVar("FENG_TEST_PATHVAR", "/usr/bin", ""); err != nil {
+		t.Fatalf("AppendToPathVar returned an error: %v", err)
+	}
+	if err := feng.PrependToPathVar("FENG_TEST_PATHVAR", "/opt/bin", ""); err != nil {
+		t.Fatalf("PrependToPathVar returned an error: %v", err)
+	}
+	if got := os.Getenv("FENG_TEST_PATHVAR"); got != want {
+		t.Errorf("Expected %s to be unchanged, but got %s", want, got)
+	}
+
+	// Test case 4: A custom separator
+	t.Setenv("FENG_TEST_PATHVAR", "a,b")
+	if err := feng.AppendToPathVar("FENG_TEST_PATHVAR", "c", ","); err != nil {
+		t.Fatalf("AppendToPathVar returned an error: %v", err)
+	}
+	if got := os.Getenv("FENG_TEST_PATHVAR"); got != "a,b,c" {
+		t.Errorf("Expected a,b,c, but got %s", got)
+	}
+}
+
+func TestGetenvBoolDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		def   bool
+		want  bool
+	}{
+		{"set true", "on", false, true},
+		{"set false", "no", true, false},
+		{"unset with true default", "", true, true},
+		{"unset with false default", "", false, false},
+		{"unparseable", "maybe", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_FEATURE_FLAG", tt.value)
+			if got := feng.GetenvBoolDefault("FENG_FEATURE_FLAG", tt.def); got != tt.want {
+				t.Errorf("Expected %t, but got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMapFromPairs(t *testing.T) {
+	// Test case 1: A valid pair list
+	got, err := feng.MapFromPairs("HOST", "localhost", "PORT", "8080")
+	if err != nil {
+		t.Fatalf("MapFromPairs returned an error: %v", err)
+	}
+	if !compareMap(map[string]string{"HOST": "localhost", "PORT": "8080"}, got) {
+		t.Errorf("Unexpected map: %v", got)
+	}
+
+	// Test case 2: An odd-length call
+	if _, err := feng.MapFromPairs("HOST", "localhost", "PORT"); err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Errorf("Expected an error naming PORT, got %v", err)
+	}
+
+	// Test case 3: A duplicate key
+	if _, err := feng.MapFromPairs("HOST", "a", "HOST", "b"); err == nil || !strings.Contains(err.Error(), "duplicate key HOST") {
+		t.Errorf("Expected a duplicate key error, got %v", err)
+	}
+
+	// Test case 4: No arguments
+	if got, err := feng.MapFromPairs(); err != nil || len(got) != 0 {
+		t.Errorf("Expected an empty map, but got %v (%v)", got, err)
+	}
+}
+
+func TestGetenvDecrypted(t *testing.T) {
+	xor := func(b []byte) ([]byte, error) {
+		out := make([]byte, len(b))
+		for i := range b {
+			out[i] = b[i] ^ 0x2a
+		}
+		return out, nil
+	}
+
+	// Test case 1: A trivial XOR decrypter, "Qk9GRkU=" is "hello" XOR 0x2a
+	t.Setenv("FENG_ENCRYPTED", "Qk9GRkU=")
+	if got, err := feng.GetenvDecrypted("FENG_ENCRYPTED", xor); err != nil || got != "hello" {
+		t.Errorf("Expected hello, but got %q (%v)", got, err)
+	}
+
+	// Test case 2: A decryption failure
+	wantErr := errors.New("access denied")
+	_, err := feng.GetenvDecrypted("FENG_ENCRYPTED", func([]byte) ([]byte, error) { return nil, wantErr })
+	if !errors.Is(err, wantErr) || !strings.Contains(err.Error(), "FENG_ENCRYPTED") {
+		t.Errorf("Expected the decrypter error naming the key, got %v", err)
+	}
+
+	// Test case 3: A bad base64 value
+	t.Setenv("FENG_ENCRYPTED", "not base64!")
+	if _, err := feng.GetenvDecrypted("FENG_ENCRYPTED", xor); err == nil || !strings.Contains(err.Error(), "base64") {
+		t.Errorf("Expected a base64 error, got %v", err)
+	}
+
+	// Test case 4: An unset key
+	os.Unsetenv("FENG_ENCRYPTED")
+	if _, err := feng.GetenvDecrypted("FENG_ENCRYPTED", xor); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("Expected a not set error, got %v", err)
+	}
+}
+
+func TestGetenvMapDelta(t *testing.T) {
+	// Test case 1: Overlapping keys with a differing value
+	t.Setenv("FENG_OLD_HOST", "db1")
+	t.Setenv("FENG_OLD_PORT", "5432")
+	t.Setenv("FENG_OLD_LEGACY", "1")
+	t.Setenv("FENG_NEW_HOST", "db2")
+	t.Setenv("FENG_NEW_PORT", "5432")
+	t.Setenv("FENG_NEW_POOL", "10")
+
+	onlyA, onlyB, differing := feng.GetenvMapDelta("FENG_OLD_", "FENG_NEW_")
+	if !compareMap(map[string]string{"LEGACY": "1"}, onlyA) {
+		t.Errorf("Unexpected onlyA: %v", onlyA)
+	}
+	if !compareMap(map[string]string{"POOL": "10"}, onlyB) {
+		t.Errorf("Unexpected onlyB: %v", onlyB)
+	}
+	if !compareMap(map[string]string{"HOST": "db2"}, differing) {
+		t.Errorf("Unexpected differing: %v", differing)
+	}
+
+	// Test case 2: Disjoint key sets
+	onlyA, onlyB, differing = feng.GetenvMapDelta("FENG_OLD_L", "FENG_NEW_P")
+	if !compareMap(map[string]string{"EGACY": "1"}, onlyA) {
+		t.Errorf("Unexpected onlyA: %v", onlyA)
+	}
+	if !compareMap(map[string]string{"ORT": "5432", "OOL": "10"}, onlyB) {
+		t.Errorf("Unexpected onlyB: %v", onlyB)
+	}
+	if len(differing) != 0 {
+		t.Errorf("Expected no differing keys, but got %v", differing)
+	}
+}
+
+func TestGetenvStringOr(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		def   string
+		trim  bool
+		want  string
+	}{
+		{"whitespace-only value with trim", "   ", " fallback ", true, "fallback"},
+		{"whitespace-only value without trim", "   ", "fallback", false, "   "},
+		{"normal value with trim", "  value\t", "fallback", true, "value"},
+		{"normal value without trim", " value ", "fallback", false, " value "},
+		{"unset", "", " fallback ", false, " fallback "},
+		{"unset with empty default", "", "", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_STRING_OR", tt.value)
+			if got := feng.GetenvStringOr("FENG_STRING_OR", tt.def, tt.trim); got != tt.want {
+				t.Errorf("Expected %q, but got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetenvMIMEType(t *testing.T) {
+	// Test case 1: A bare type
+	t.Setenv("FENG_DEFAULT_MIME", "application/json")
+	mediatype, params, err := feng.GetenvMIMEType("FENG_DEFAULT_MIME")
+	if err != nil || mediatype != "application/json" || len(params) != 0 {
+		t.Errorf("Unexpected result: %q %v (%v)", mediatype, params, err)
+	}
+
+	// Test case 2: A type with parameters
+	t.Setenv("FENG_DEFAULT_MIME", "Text/Plain; charset=utf-8; format=flowed")
+	mediatype, params, err = feng.GetenvMIMEType("FENG_DEFAULT_MIME")
+	if err != nil || mediatype != "text/plain" {
+		t.Fatalf("Unexpected result: %q (%v)", mediatype, err)
+	}
+	if !compareMap(map[string]string{"charset": "utf-8", "format": "flowed"}, params) {
+		t.Errorf("Unexpected params: %v", params)
+	}
+
+	// Test case 3: Invalid and unset values
+	for _, value := range []string{"text/", "text/plain; charset", ""} {
+		t.Setenv("FENG_DEFAULT_MIME", value)
+		if _, _, err := feng.GetenvMIMEType("FENG_DEFAULT_MIME"); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestGetenvMapNormalized(t *testing.T) {
+	// Test case 1: Keys that don't collide
+	t.Setenv("FENG_NORM_Host", "db")
+	t.Setenv("FENG_NORM_port", "5432")
+	got, err := feng.GetenvMapNormalized("FENG_NORM_", strings.ToUpper)
+	if err != nil {
+		t.Fatalf("GetenvMapNormalized returned an error: %v", err)
+	}
+	if !compareMap(map[string]string{"FENG_NORM_HOST": "db", "FENG_NORM_PORT": "5432"}, got) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+
+	// Test case 2: Keys that collide after normalization
+	t.Setenv("FENG_NORM_HOST", "db2")
+	got, err = feng.GetenvMapNormalized("FENG_NORM_", strings.ToUpper)
+	if err == nil || !strings.Contains(err.Error(), "FENG_NORM_HOST (from FENG_NORM_HOST, FENG_NORM_Host)") {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+	if got != nil {
+		t.Errorf("Expected a nil map on collision, but got %v", got)
+	}
+}
+
+func TestGetenvTopicSpecs(t *testing.T) {
+	// Test case 1: A well-formed list
+	t.Setenv("FENG_TOPICS", "orders:3, payments : 1,")
+	got, err := feng.GetenvTopicSpecs("FENG_TOPICS")
+	if err != nil {
+		t.Fatalf("GetenvTopicSpecs returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(got, map[string]int{"orders": 3, "payments": 1}) {
+		t.Errorf("Unexpected specs: %v", got)
+	}
+
+	// Test case 2: A duplicate topic
+	t.Setenv("FENG_TOPICS", "orders:3,orders:4")
+	if _, err := feng.GetenvTopicSpecs("FENG_TOPICS"); err == nil || !strings.Contains(err.Error(), "duplicate topic orders") {
+		t.Errorf("Expected a duplicate topic error, got %v", err)
+	}
+
+	// Test case 3: Malformed entries
+	for _, value := range []string{"orders", "orders:", ":3", "orders:three", "orders:0", ""} {
+		t.Setenv("FENG_TOPICS", value)
+		if _, err := feng.GetenvTopicSpecs("FENG_TOPICS"); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestRequireAllOrNone(t *testing.T) {
+	keys := []string{"FENG_SMTP_USER", "FENG_SMTP_PASS", "FENG_SMTP_HOST"}
+
+	// Test case 1: None set
+	for _, k := range keys {
+		t.Setenv(k, "")
+	}
+	if err := feng.RequireAllOrNone(keys...); err != nil {
+		t.Errorf("Expected no error when none is set, got %v", err)
+	}
+
+	// Test case 2: Partially set
+	t.Setenv("FENG_SMTP_USER", "mailer")
+	err := feng.RequireAllOrNone(keys...)
+	if err == nil || !strings.Contains(err.Error(), "FENG_SMTP_PASS, FENG_SMTP_HOST is not set") {
+		t.Errorf("Expected an error naming the missing keys, got %v", err)
+	}
+
+	// Test case 3: All set
+	t.Setenv("FENG_SMTP_PASS", "secret")
+	t.Setenv("FENG_SMTP_HOST", "smtp.example.com")
+	if err := feng.RequireAllOrNone(keys...); err != nil {
+		t.Errorf("Expected no error when all are set, got %v", err)
+	}
+}
+
+func TestGetenvDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30s", 30 * time.Second, false},
+		{"5m", 5 * time.Minute, false},
+		{" 1h30m ", 90 * time.Minute, false},
+		{"30", 0, true},
+		{"soon", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_DURATION", tt.value)
+		got, err := feng.GetenvDuration("FENG_DURATION")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%q: expected (%v, error %t), but got (%v, %v)", tt.value, tt.want, tt.wantErr, got, err)
+		}
+	}
+
+	// Defaults apply only when the variable is unset
+	t.Setenv("FENG_DURATION", "")
+	if got, err := feng.GetenvDurationOrDefault("FENG_DURATION", time.Minute); err != nil || got != time.Minute {
+		t.Errorf("Expected the default, but got %v (%v)", got, err)
+	}
+	t.Setenv("FENG_DURATION", "2s")
+	if got, err := feng.GetenvDurationOrDefault("FENG_DURATION", time.Minute); err != nil || got != 2*time.Second {
+		t.Errorf("Expected 2s, but got %v (%v)", got, err)
+	}
+	t.Setenv("FENG_DURATION", "2 seconds")
+	if _, err := feng.GetenvDurationOrDefault("FENG_DURATION", time.Minute); err == nil {
+		t.Error("Expected an error for an invalid value")
+	}
+}
+
+func TestGetenvTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		layouts []string
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC3339 by default", "2024-03-01T12:00:00Z", nil, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), false},
+		{"fallback layout", "2024-03-01", []string{"2006-01-02"}, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"unix seconds", "1709294400", []string{feng.LayoutUnix}, time.Unix(1709294400, 0), false},
+		{"unix millis", "1709294400123", []string{"2006-01-02", feng.LayoutUnixMilli}, time.UnixMilli(1709294400123), false},
+		{"no layout matches", "2024-03-01", nil, time.Time{}, true},
+		{"not a timestamp", "yesterday", []string{feng.LayoutUnix}, time.Time{}, true},
+		{"unset", "", nil, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_DEPLOYED_AT", tt.value)
+			got, err := feng.GetenvTime("FENG_DEPLOYED_AT", tt.layouts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error state: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetenvURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		schemes []string
+		wantErr string
+	}{
+		{"any scheme", "http://localhost:8080/api", nil, ""},
+		{"allowed scheme", "HTTPS://api.example.com/v1?x=1", []string{"https"}, ""},
+		{"disallowed scheme", "http://api.example.com", []string{"https"}, "is not one of https"},
+		{"missing scheme", "api.example.com/v1", nil, "expected scheme://"},
+		{"host and port only", "localhost:8080", nil, "expected scheme://"},
+		{"unparseable", "http://[::1", nil, "failed to parse"},
+		{"empty authority", "https://", nil, "missing host"},
+		{"single slash", "http:/path", nil, "missing host"},
+		{"empty host with path", "https:///x", nil, "missing host"},
+		{"unset", "", nil, "not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FENG_ENDPOINT", tt.value)
+			u, err := feng.GetenvURL("FENG_ENDPOINT", tt.schemes...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetenvURL returned an error: %v", err)
+			}
+			if u.String() != tt.value && !strings.EqualFold(u.String(), tt.value) {
+				t.Errorf("Expected %s, but got %s", tt.value, u)
+			}
+		})
+	}
+}
+
+func TestGetenvIP(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"10.0.0.5", "10.0.0.5", false},
+		{" ::1 ", "::1", false},
+		{"2001:db8::1", "2001:db8::1", false},
+		{"10.0.0.256", "", true},
+		{"localhost", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_BIND_ADDR", tt.value)
+		got, err := feng.GetenvIP("FENG_BIND_ADDR")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("%q: expected %s, but got %s", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestGetenvCIDR(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"10.0.0.0/8", "10.0.0.0/8", false},
+		{"10.1.2.3/8", "10.0.0.0/8", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"10.0.0.0", "", true},
+		{"10.0.0.0/33", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_ALLOWED_NET", tt.value)
+		got, err := feng.GetenvCIDR("FENG_ALLOWED_NET")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("%q: expected %s, but got %s", tt.value, tt.want, got)
+		}
+	}
+
+	// The network can be used for membership checks
+	t.Setenv("FENG_ALLOWED_NET", "192.168.0.0/16")
+	ipNet, err := feng.GetenvCIDR("FENG_ALLOWED_NET")
+	if err != nil || !ipNet.Contains(net.ParseIP("192.168.4.2")) || ipNet.Contains(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Unexpected network %v (%v)", ipNet, err)
+	}
+}
+
+func TestGetenvIntSlice(t *testing.T) {
+	// Test case 1: A list of ints
+	t.Setenv("FENG_PORTS", "80, 443 ,0x1F90")
+	got, err := feng.GetenvIntSlice("FENG_PORTS", ",")
+	if err != nil || !reflect.DeepEqual(got, []int{80, 443, 8080}) {
+		t.Errorf("Expected [80 443 8080], but got %v (%v)", got, err)
+	}
+
+	// Test case 2: A list of int64s with a custom separator
+	t.Setenv("FENG_SHARDS", "9007199254740993;-1")
+	got64, err := feng.GetenvInt64Slice("FENG_SHARDS", ";")
+	if err != nil || !reflect.DeepEqual(got64, []int64{9007199254740993, -1}) {
+		t.Errorf("Expected [9007199254740993 -1], but got %v (%v)", got64, err)
+	}
+
+	// Test case 3: The error names the offending index
+	t.Setenv("FENG_PORTS", "80,http,443")
+	if _, err := feng.GetenvIntSlice("FENG_PORTS", ","); err == nil || !strings.Contains(err.Error(), "element 1 of environment variable FENG_PORTS") {
+		t.Errorf("Expected an error naming element 1, got %v", err)
+	}
+	t.Setenv("FENG_PORTS", "80,,443")
+	if _, err := feng.GetenvInt64Slice("FENG_PORTS", ","); err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Errorf("Expected an error naming element 1, got %v", err)
+	}
+
+	// Test case 4: An escaped separator stays inside its element
+	t.Setenv("FENG_PORTS", `80\,443,8080`)
+	if _, err := feng.GetenvIntSlice("FENG_PORTS", ","); err == nil || !strings.Contains(err.Error(), `element 0 of environment variable FENG_PORTS as int: strconv.ParseInt: parsing "80,443"`) {
+		t.Errorf("Expected an error naming element 0 as \"80,443\", got %v", err)
+	}
+
+	// Test case 5: An unset variable
+	t.Setenv("FENG_PORTS", "")
+	if got, err := feng.GetenvIntSlice("FENG_PORTS", ","); err != nil || len(got) != 0 {
+		t.Errorf("Expected an empty slice, but got %v (%v)", got, err)
+	}
+}
+
+func TestGetenvKVMap(t *testing.T) {
+	// Test case 1: Default-style separators
+	t.Setenv("FENG_LABELS", "a=1, b = 2,c=x=y,")
+	got, err := feng.GetenvKVMap("FENG_LABELS", ",", "=")
+	if err != nil {
+		t.Fatalf("GetenvKVMap returned an error: %v", err)
+	}
+	if !compareMap(map[string]string{"a": "1", "b": "2", "c": "x=y"}, got) {
+		t.Errorf("Unexpected map: %v", got)
+	}
+
+	// Test case 2: Custom separators
+	t.Setenv("FENG_LABELS", "team:core;tier:gold")
+	got, err = feng.GetenvKVMap("FENG_LABELS", ";", ":")
+	if err != nil || !compareMap(map[string]string{"team": "core", "tier": "gold"}, got) {
+		t.Errorf("Unexpected map: %v (%v)", got, err)
+	}
+
+	// Test case 3: Malformed pairs
+	for _, value := range []string{"a=1,b", "=1"} {
+		t.Setenv("FENG_LABELS", value)
+		if _, err := feng.GetenvKVMap("FENG_LABELS", ",", "="); err == nil || !strings.Contains(err.Error(), "FENG_LABELS") {
+			t.Errorf("%q: expected an error naming FENG_LABELS, got %v", value, err)
+		}
+	}
+
+	// Test case 4: An unset variable
+	t.Setenv("FENG_LABELS", "")
+	if got, err := feng.GetenvKVMap("FENG_LABELS", ",", "="); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty map, but got %v (%v)", got, err)
+	}
+}
+
+func TestGetenvJSON(t *testing.T) {
+	type credentials struct {
+		User string   `json:"user"`
+		Port int      `json:"port"`
+		Tags []string `json:"tags"`
+	}
+
+	// Test case 1: Decoding into a struct
+	t.Setenv("FENG_BINDING", `{"user":"app","port":5432,"tags":["a","b"]}`)
+	var creds credentials
+	if err := feng.GetenvJSON("FENG_BINDING", &creds); err != nil {
+		t.Fatalf("GetenvJSON returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(creds, credentials{User: "app", Port: 5432, Tags: []string{"a", "b"}}) {
+		t.Errorf("Unexpected result: %+v", creds)
+	}
+
+	// Test case 2: Decoding into a map
+	var m map[string]interface{}
+	if err := feng.GetenvJSON("FENG_BINDING", &m); err != nil || m["user"] != "app" {
+		t.Errorf("Unexpected map: %v (%v)", m, err)
+	}
+
+	// Test case 3: Invalid JSON, a mismatched type and an unset variable
+	t.Setenv("FENG_BINDING", `{"user":`)
+	if err := feng.GetenvJSON("FENG_BINDING", &creds); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+	t.Setenv("FENG_BINDING", `{"port":"5432"}`)
+	if err := feng.GetenvJSON("FENG_BINDING", &creds); err == nil {
+		t.Error("Expected an error for a mismatched type")
+	}
+	t.Setenv("FENG_BINDING", "")
+	if err := feng.GetenvJSON("FENG_BINDING", &creds); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("Expected a not set error, got %v", err)
+	}
+}
+
+func TestGetenvBase64AndHex(t *testing.T) {
+	want := []byte{0xfb, 0xff, 0x01}
+
+	// Test case 1: Standard and URL-safe base64
+	t.Setenv("FENG_SECRET", "+/8B")
+	if got, err := feng.GetenvBase64("FENG_SECRET"); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Expected %x, but got %x (%v)", want, got, err)
+	}
+	if _, err := feng.GetenvBase64With("FENG_SECRET", base64.URLEncoding); err == nil {
+		t.Error("Expected URL-safe decoding to reject + and /")
+	}
+	t.Setenv("FENG_SECRET", "-_8B")
+	if got, err := feng.GetenvBase64With("FENG_SECRET", base64.URLEncoding); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Expected %x, but got %x (%v)", want, got, err)
+	}
+
+	// Test case 2: Strict decoding rejects non-zero padding bits
+	t.Setenv("FENG_SECRET", "+/9=")
+	if _, err := feng.GetenvBase64("FENG_SECRET"); err != nil {
+		t.Errorf("Expected lenient decoding to succeed, got %v", err)
+	}
+	if _, err := feng.GetenvBase64With("FENG_SECRET", base64.StdEncoding.Strict()); err == nil {
+		t.Error("Expected strict decoding to fail")
+	}
+
+	// Test case 3: Hex in either case
+	t.Setenv("FENG_SECRET", "FBff01")
+	if got, err := feng.GetenvHex("FENG_SECRET"); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Expected %x, but got %x (%v)", want, got, err)
+	}
+
+	// Test case 4: Invalid and unset values
+	t.Setenv("FENG_SECRET", "xyz")
+	if _, err := feng.GetenvHex("FENG_SECRET"); err == nil {
+		t.Error("Expected an error for invalid hex")
+	}
+	t.Setenv("FENG_SECRET", "not base64!")
+	if _, err := feng.GetenvBase64("FENG_SECRET"); err == nil {
+		t.Error("Expected an error for invalid base64")
+	}
+	t.Setenv("FENG_SECRET", "")
+	if _, err := feng.GetenvBase64("FENG_SECRET"); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("Expected a not set error, got %v", err)
+	}
+}
+
+func TestGetenvByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"512KB", 512000, false},
+		{"512kib", 512 << 10, false},
+		{"10MiB", 10 << 20, false},
+		{"2G", 2e9, false},
+		{"1.5 GiB", 3 << 29, false},
+		{"100 b", 100, false},
+		{"8EiB", 0, true},
+		{"10000000PB", 0, true},
+		{"-1KB", 0, true},
+		{"10XB", 0, true},
+		{"KB", 0, true},
+		{"1..5MB", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_CACHE_SIZE", tt.value)
+		got, err := feng.GetenvByteSize("FENG_CACHE_SIZE")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%q: expected (%d, error %t), but got (%d, %v)", tt.value, tt.want, tt.wantErr, got, err)
+		}
+	}
+
+	// A negative size is reported as such rather than as an unknown unit
+	for _, value := range []string{"-1KB", "-0.5 MiB", "-10"} {
+		t.Setenv("FENG_CACHE_SIZE", value)
+		if _, err := feng.GetenvByteSize("FENG_CACHE_SIZE"); err == nil || !strings.Contains(err.Error(), "must not be negative") {
+			t.Errorf("%q: expected a negative size error, got %v", value, err)
+		}
+	}
+}
+
+func TestGetenvUUID(t *testing.T) {
+	const canonical = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{canonical, false},
+		{"6BA7B810-9DAD-11D1-80B4-00C04FD430C8", false},
+		{"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}", false},
+		{"urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8", false},
+		{"6ba7b8109dad11d180b400c04fd430c8", false},
+		{"6ba7b810-9dad-11d1-80b4-00c04fd430c", true},
+		{"6ba7b810+9dad-11d1-80b4-00c04fd430c8", true},
+		{"zba7b810-9dad-11d1-80b4-00c04fd430c8", true},
+		{"6ba7b81-09dad-11d1-80b4-00c04fd430c8", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_TENANT_ID", tt.value)
+		got, err := feng.GetenvUUID("FENG_TENANT_ID")
+		if tt.wantErr {
+			var uuidErr *feng.InvalidUUIDError
+			if !errors.As(err, &uuidErr) || uuidErr.Value != tt.value {
+				t.Errorf("%q: expected an *InvalidUUIDError, got %v", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || got != canonical {
+			t.Errorf("%q: expected %s, but got %q (%v)", tt.value, canonical, got, err)
+		}
+	}
+
+	// An unset variable is not a format error
+	t.Setenv("FENG_TENANT_ID", "")
+	var uuidErr *feng.InvalidUUIDError
+	if _, err := feng.GetenvUUID("FENG_TENANT_ID"); err == nil || errors.As(err, &uuidErr) {
+		t.Errorf("Expected a plain not set error, got %v", err)
+	}
+}
+
+func TestGetenvEnum(t *testing.T) {
+	modes := []string{"dev", "staging", "prod"}
+
+	// Test case 1: An allowed value
+	t.Setenv("FENG_MODE", " staging ")
+	if got, err := feng.GetenvEnum("FENG_MODE", modes...); err != nil || got != "staging" {
+		t.Errorf("Expected staging, but got %q (%v)", got, err)
+	}
+
+	// Test case 2: Case matters unless folding is requested
+	t.Setenv("FENG_MODE", "PROD")
+	if _, err := feng.GetenvEnum("FENG_MODE", modes...); err == nil || !strings.Contains(err.Error(), "must be one of dev, staging, prod") {
+		t.Errorf("Expected an error listing the allowed values, got %v", err)
+	}
+	if got, err := feng.GetenvEnumFold("FENG_MODE", modes...); err != nil || got != "prod" {
+		t.Errorf("Expected prod, but got %q (%v)", got, err)
+	}
+
+	// Test case 3: A disallowed and an unset value
+	t.Setenv("FENG_MODE", "qa")
+	if _, err := feng.GetenvEnumFold("FENG_MODE", modes...); err == nil {
+		t.Error("Expected an error for a disallowed value")
+	}
+	t.Setenv("FENG_MODE", "")
+	if _, err := feng.GetenvEnum("FENG_MODE", modes...); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("Expected a not set error, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package feng
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// variableRegx matches `$VAR` and `${VAR}` references, optionally escaped with a backslash.
+var variableRegx = regexp.MustCompile(`(\\)?\$(?:\{([A-Za-z_][A-Za-z0-9_.]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// UnresolvedRef describes a variable reference that could not be resolved during interpolation.
+type UnresolvedRef struct {
+	Name string
+	File string
+	Line int
+}
+
+// ExpandError is returned in strict mode when one or more references could not be resolved.
+type ExpandError struct {
+	Refs []UnresolvedRef
+}
+
+// Error lists every unresolved reference together with where it was found.
+func (e *ExpandError) Error() string {
+	refs := make([]string, 0, len(e.Refs))
+	for _, r := range e.Refs {
+		refs = append(refs, fmt.Sprintf("%s (%s:%d)", r.Name, r.File, r.Line))
+	}
+	return "unresolved variable references: " + strings.Join(refs, ", ")
+}
+
+// expandVars replaces `$VAR` and `${VAR}` references in s using lookup.
+//
+// Unknown variables expand to the empty string and their names are returned in missing.
+// A reference preceded by a backslash is kept literally without the backslash.
+func expandVars(s string, lookup func(string) (string, bool)) (expanded string, missing []string) {
+	expanded = variableRegx.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref[0] == '\\' {
+			return ref[1:]
+		}
+		parts := variableRegx.FindStringSubmatch(ref)
+		name := parts[2]
+		if name == "" {
+			name = parts[3]
+		}
+		if v, ok := lookup(name); ok {
+			return v
+		}
+		missing = append(missing, name)
+		return ""
+	})
+	return expanded, missing
+}
+
+// LoadExpand loads environment files like Load, expanding `$VAR` and `${VAR}` references
+// in values.
+//
+// References are resolved against keys defined earlier in the same or a previous file,
+// then against the process environment. Unknown references expand to the empty string.
+// Single-quoted values and heredoc bodies are taken literally.
+func LoadExpand(filenames ...string) error {
+	return LoadWith(WithFiles(filenames...), WithInterpolation(true))
+}
+
+// LoadExpandStrict behaves like LoadExpand but fails when any reference cannot be resolved.
+//
+// All unresolved references are collected and returned as an *ExpandError carrying the
+// file name and line number of each one. No variables are set when an error is returned.
+func LoadExpandStrict(filenames ...string) error {
+	return LoadWith(WithFiles(filenames...), WithStrictInterpolation(true))
+}
+
+// ExpandFile renders the template file src against the current environment and writes
+// the result to dst.
+//
+// `$VAR` and `${VAR}` references are replaced with the values from os.Getenv, and unknown
+// variables expand to the empty string. The output file is created with the permissions
+// of src.
+func ExpandFile(src, dst string) error {
+	return expandFile(src, dst, false)
+}
+
+// ExpandFileStrict behaves like ExpandFile but returns an *ExpandError listing every
+// unresolved reference instead of writing dst.
+func ExpandFileStrict(src, dst string) error {
+	return expandFile(src, dst, true)
+}
+
+// expandFile implements ExpandFile and ExpandFileStrict.
+func expandFile(src, dst string, strict bool) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	var unresolved []UnresolvedRef
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		var missing []string
+		lines[i], missing = expandVars(line, os.LookupEnv)
+		for _, name := range missing {
+			unresolved = append(unresolved, UnresolvedRef{Name: name, File: src, Line: i + 1})
+		}
+	}
+
+	if strict && len(unresolved) > 0 {
+		return &ExpandError{Refs: unresolved}
+	}
+	return os.WriteFile(dst, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
+
+// GetenvTemplate executes tmpl as a text/template with the current environment as
+// its data, e.g. "{{.DB_HOST}}:{{.DB_PORT}}".
+//
+// Variables that are not set render as the empty string.
+func GetenvTemplate(tmpl string) (string, error) {
+	return executeEnvTemplate(tmpl, "missingkey=zero")
+}
+
+// GetenvTemplateStrict behaves like GetenvTemplate but fails when the template
+// references a variable that is not set.
+func GetenvTemplateStrict(tmpl string) (string, error) {
+	return executeEnvTemplate(tmpl, "missingkey=error")
+}
+
+// executeEnvTemplate parses and executes tmpl against GetenvMap("") with the given
+// template option.
+func executeEnvTemplate(tmpl, option string) (string, error) {
+	t, err := template.New("env").Option(option).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, GetenvMap("")); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,129 @@
+package feng_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+// writeTempEnv writes content to a new env file in a temporary directory and returns its path.
+func writeTempEnv(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return filename
+}
+
+func TestLoadExpand(t *testing.T) {
+	t.Setenv("FENG_EXP_HOST", "")
+	t.Setenv("FENG_EXP_URL", "")
+	t.Setenv("FENG_EXP_LITERAL", "")
+	t.Setenv("FENG_EXP_BROKEN", "")
+
+	// Test case 1: All references resolve
+	t.Run("All references resolve", func(t *testing.T) {
+		filename := writeTempEnv(t, "FENG_EXP_HOST=db\nFENG_EXP_URL=\"postgres://${FENG_EXP_HOST}:5432\"\nFENG_EXP_LITERAL='$FENG_EXP_HOST'\n")
+		if err := feng.LoadExpandStrict(filename); err != nil {
+			t.Fatalf("LoadExpandStrict returned an error: %v", err)
+		}
+		if got := os.Getenv("FENG_EXP_URL"); got != "postgres://db:5432" {
+			t.Errorf("Expected postgres://db:5432, but got %s", got)
+		}
+		if got := os.Getenv("FENG_EXP_LITERAL"); got != "$FENG_EXP_HOST" {
+			t.Errorf("Expected single-quoted value to stay literal, but got %s", got)
+		}
+	})
+
+	// Test case 2: Missing reference
+	t.Run("Missing reference", func(t *testing.T) {
+		filename := writeTempEnv(t, "# comment\nFENG_EXP_BROKEN=${FENG_EXP_TYPO}/x\n")
+
+		err := feng.LoadExpandStrict(filename)
+		var expandErr *feng.ExpandError
+		if !errors.As(err, &expandErr) {
+			t.Fatalf("Expected an ExpandError, got %v", err)
+		}
+		if len(expandErr.Refs) != 1 || expandErr.Refs[0].Name != "FENG_EXP_TYPO" || expandErr.Refs[0].Line != 2 {
+			t.Errorf("Unexpected unresolved references: %+v", expandErr.Refs)
+		}
+
+		if err := feng.LoadExpand(filename); err != nil {
+			t.Fatalf("LoadExpand returned an error: %v", err)
+		}
+		if got := os.Getenv("FENG_EXP_BROKEN"); got != "/x" {
+			t.Errorf("Expected missing reference to expand empty, but got %s", got)
+		}
+	})
+}
+
+func TestExpandFile(t *testing.T) {
+	t.Setenv("FENG_TPL_HOST", "example.com")
+	t.Setenv("FENG_TPL_UNSET", "")
+	os.Unsetenv("FENG_TPL_UNSET")
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.conf.tmpl")
+	dst := filepath.Join(dir, "app.conf")
+	template := "server_name ${FENG_TPL_HOST};\nroot /srv/$FENG_TPL_UNSET;\nliteral \\$FENG_TPL_HOST\n"
+	if err := os.WriteFile(src, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// Test case 1: Unset variables expand to empty
+	if err := feng.ExpandFile(src, dst); err != nil {
+		t.Fatalf("ExpandFile returned an error: %v", err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("Failed to read rendered file: %v", err)
+	}
+	want := "server_name example.com;\nroot /srv/;\nliteral $FENG_TPL_HOST\n"
+	if string(got) != want {
+		t.Errorf("Expected %q, but got %q", want, got)
+	}
+
+	// Test case 2: Strict mode reports the unset variable
+	err = feng.ExpandFileStrict(src, filepath.Join(dir, "strict.conf"))
+	var expandErr *feng.ExpandError
+	if !errors.As(err, &expandErr) {
+		t.Fatalf("Expected an ExpandError, got %v", err)
+	}
+	if len(expandErr.Refs) != 1 || expandErr.Refs[0].Name != "FENG_TPL_UNSET" || expandErr.Refs[0].Line != 2 {
+		t.Errorf("Unexpected unresolved references: %+v", expandErr.Refs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "strict.conf")); !os.IsNotExist(err) {
+		t.Error("Expected strict mode not to write the output file")
+	}
+}
+
+func TestGetenvTemplate(t *testing.T) {
+	t.Setenv("FENG_TMPL_HOST", "db.internal")
+	t.Setenv("FENG_TMPL_PORT", "5432")
+	t.Setenv("FENG_TMPL_TLS", "true")
+
+	// Test case 1: Simple template
+	got, err := feng.GetenvTemplate("{{.FENG_TMPL_HOST}}:{{.FENG_TMPL_PORT}}")
+	if err != nil || got != "db.internal:5432" {
+		t.Errorf("Expected db.internal:5432, got %q (%v)", got, err)
+	}
+
+	// Test case 2: Conditional
+	got, err = feng.GetenvTemplate(`{{if eq .FENG_TMPL_TLS "true"}}sslmode=require{{else}}sslmode=disable{{end}}`)
+	if err != nil || got != "sslmode=require" {
+		t.Errorf("Expected sslmode=require, got %q (%v)", got, err)
+	}
+
+	// Test case 3: Missing key renders empty, or fails in strict mode
+	got, err = feng.GetenvTemplate("[{{.FENG_TMPL_MISSING}}]")
+	if err != nil || got != "[]" {
+		t.Errorf("Expected [], got %q (%v)", got, err)
+	}
+	if _, err := feng.GetenvTemplateStrict("[{{.FENG_TMPL_MISSING}}]"); err == nil {
+		t.Error("Expected an error for a missing key in strict mode")
+	}
+}
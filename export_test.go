@@ -0,0 +1,4 @@
+package feng
+
+// ParseEnviron exposes parseEnviron to the external tests.
+var ParseEnviron = parseEnviron
@@ -0,0 +1,37 @@
+// Package fengtest provides helpers for setting environment variables in tests of code
+// that reads its configuration with feng. It is kept apart from feng so that the
+// testing package is not linked into programs that only load configuration.
+package fengtest
+
+import (
+	"os"
+	"testing"
+)
+
+// SetForTest sets the environment variable key to value for the duration of a test.
+//
+// A cleanup is registered with t that restores the previous value, or unsets the
+// variable if it was not set before. Unlike testing.T.Setenv it can be used with any
+// testing.TB, including benchmarks.
+func SetForTest(t testing.TB, key, value string) {
+	t.Helper()
+	prev, existed := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("fengtest: failed to set %s: %v", key, err)
+	}
+	t.Cleanup(func() {
+		if existed {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+// SetMapForTest calls SetForTest for every key-value pair in envMap.
+func SetMapForTest(t testing.TB, envMap map[string]string) {
+	t.Helper()
+	for k, v := range envMap {
+		SetForTest(t, k, v)
+	}
+}
@@ -0,0 +1,34 @@
+package fengtest_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nosusume/feng/fengtest"
+)
+
+func TestSetForTest(t *testing.T) {
+	t.Setenv("FENG_TEST_EXISTING", "before")
+	t.Setenv("FENG_TEST_NEW", "")
+	os.Unsetenv("FENG_TEST_NEW")
+
+	t.Run("nested", func(t *testing.T) {
+		fengtest.SetForTest(t, "FENG_TEST_EXISTING", "during")
+		fengtest.SetMapForTest(t, map[string]string{"FENG_TEST_NEW": "added"})
+
+		if got := os.Getenv("FENG_TEST_EXISTING"); got != "during" {
+			t.Errorf("Expected during, but got %s", got)
+		}
+		if got := os.Getenv("FENG_TEST_NEW"); got != "added" {
+			t.Errorf("Expected added, but got %s", got)
+		}
+	})
+
+	// The subtest's cleanups have run at this point
+	if got := os.Getenv("FENG_TEST_EXISTING"); got != "before" {
+		t.Errorf("Expected the previous value to be restored, but got %s", got)
+	}
+	if _, ok := os.LookupEnv("FENG_TEST_NEW"); ok {
+		t.Error("Expected a variable that did not exist to be unset again")
+	}
+}
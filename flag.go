@@ -0,0 +1,39 @@
+package feng
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BindFlagSet fills the flags of fs that were not given on the command line from the
+// environment.
+//
+// Each flag is looked up as prefix followed by its name in upper case, with dashes and
+// dots replaced by underscores, so the flag "max-conns" with prefix "APP_" is read from
+// APP_MAX_CONNS. Explicit command-line values take precedence over the environment, so
+// BindFlagSet must be called after fs.Parse. An error is returned for the first value
+// that the flag rejects.
+func BindFlagSet(fs *flag.FlagSet, prefix string) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || explicit[f.Name] {
+			return
+		}
+		key := prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(f.Name))
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value for flag -%s from environment variable %s: %w", f.Name, key, setErr)
+		}
+	})
+	return err
+}
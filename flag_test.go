@@ -0,0 +1,63 @@
+package feng_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestBindFlagSet(t *testing.T) {
+	newFlagSet := func() (*flag.FlagSet, *string, *int, *bool) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		host := fs.String("host", "localhost", "")
+		port := fs.Int("max-port", 80, "")
+		debug := fs.Bool("debug", false, "")
+		return fs, host, port, debug
+	}
+	t.Setenv("FENG_FLAG_HOST", "example.com")
+	t.Setenv("FENG_FLAG_MAX_PORT", "8080")
+	t.Setenv("FENG_FLAG_DEBUG", "true")
+
+	// Test case 1: Environment values populate unset flags
+	t.Run("Environment defaults", func(t *testing.T) {
+		fs, host, port, debug := newFlagSet()
+		if err := fs.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := feng.BindFlagSet(fs, "FENG_FLAG_"); err != nil {
+			t.Fatalf("BindFlagSet returned an error: %v", err)
+		}
+		if *host != "example.com" || *port != 8080 || !*debug {
+			t.Errorf("Unexpected flag values: %s %d %t", *host, *port, *debug)
+		}
+	})
+
+	// Test case 2: Command-line values win
+	t.Run("Command line wins", func(t *testing.T) {
+		fs, host, port, debug := newFlagSet()
+		if err := fs.Parse([]string{"-host", "cli.example.com", "-debug=false"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := feng.BindFlagSet(fs, "FENG_FLAG_"); err != nil {
+			t.Fatalf("BindFlagSet returned an error: %v", err)
+		}
+		if *host != "cli.example.com" || *port != 8080 || *debug {
+			t.Errorf("Unexpected flag values: %s %d %t", *host, *port, *debug)
+		}
+	})
+
+	// Test case 3: An invalid environment value
+	t.Run("Invalid value", func(t *testing.T) {
+		t.Setenv("FENG_FLAG_MAX_PORT", "eighty")
+		fs, _, _, _ := newFlagSet()
+		if err := fs.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := feng.BindFlagSet(fs, "FENG_FLAG_"); err == nil {
+			t.Error("Expected an error for a non-integer value")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package feng
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// Value lists the types supported by Get. It includes time.Duration, whose underlying
+// type is int64, and other named types built on the listed kinds.
+type Value interface {
+	~string | ~bool |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Get reads the environment variable named by key and parses it as T, e.g.
+// feng.Get[int]("PORT") or feng.Get[time.Duration]("TIMEOUT").
+//
+// Values are parsed as by Unmarshal: numbers are trimmed and integers follow the base
+// prefix rules of GetenvInt, and time.Duration uses time.ParseDuration. Booleans use
+// the extended grammar of GetenvBoolDefault and GetenvTristate, so "yes", "on" and
+// "enabled" are true; note that the older GetenvBool accepts only the forms of
+// strconv.ParseBool. Strings are returned verbatim. Named types implementing
+// encoding.TextUnmarshaler, such as Level, are parsed with UnmarshalText; other named
+// types are parsed by their underlying kind. Unlike some of the older typed
+// getters, which return the zero value for an unset variable, Get always returns an
+// error when the variable is not set or empty.
+func Get[T Value](key string) (T, error) {
+	var v T
+	value := os.Getenv(key)
+	if value == "" {
+		return v, fmt.Errorf("environment variable %s not set", key)
+	}
+	if err := parseValue(value, reflect.ValueOf(&v).Elem()); err != nil {
+		return v, fmt.Errorf("failed to parse environment variable %s as %s: %w", key, typeName[T](), err)
+	}
+	return v, nil
+}
+
+// typeName returns the name of T for error messages.
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+// GetOr is like Get but returns def when the variable is not set, empty or cannot be
+// parsed as T, e.g. feng.GetOr("WORKERS", 4).
+func GetOr[T Value](key string, def T) T {
+	v, err := Get[T](key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetOrErr is like GetOr but reports a value that cannot be parsed as an error instead
+// of silently replacing it with def. Only an unset or empty variable yields def.
+func GetOrErr[T Value](key string, def T) (T, error) {
+	if os.Getenv(key) == "" {
+		return def, nil
+	}
+	return Get[T](key)
+}
@@ -0,0 +1,99 @@
+package feng_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nosusume/feng"
+)
+
+func TestGet(t *testing.T) {
+	// Test case 1: Every supported kind
+	t.Setenv("FENG_G_INT", " 0x10 ")
+	t.Setenv("FENG_G_UINT8", "255")
+	t.Setenv("FENG_G_FLOAT", "0.25")
+	t.Setenv("FENG_G_BOOL", "true")
+	t.Setenv("FENG_G_STRING", " padded ")
+	t.Setenv("FENG_G_DURATION", "1h30m")
+
+	if v, err := feng.Get[int]("FENG_G_INT"); err != nil || v != 16 {
+		t.Errorf("Get[int]: expected 16, but got %d (%v)", v, err)
+	}
+	if v, err := feng.Get[uint8]("FENG_G_UINT8"); err != nil || v != 255 {
+		t.Errorf("Get[uint8]: expected 255, but got %d (%v)", v, err)
+	}
+	if v, err := feng.Get[float64]("FENG_G_FLOAT"); err != nil || v != 0.25 {
+		t.Errorf("Get[float64]: expected 0.25, but got %v (%v)", v, err)
+	}
+	if v, err := feng.Get[bool]("FENG_G_BOOL"); err != nil || !v {
+		t.Errorf("Get[bool]: expected true, but got %t (%v)", v, err)
+	}
+	if v, err := feng.Get[string]("FENG_G_STRING"); err != nil || v != " padded " {
+		t.Errorf("Get[string]: expected %q, but got %q (%v)", " padded ", v, err)
+	}
+	if v, err := feng.Get[time.Duration]("FENG_G_DURATION"); err != nil || v != 90*time.Minute {
+		t.Errorf("Get[time.Duration]: expected 1h30m, but got %v (%v)", v, err)
+	}
+	t.Setenv("FENG_G_LEVEL", "WARNING")
+	if v, err := feng.Get[feng.Level]("FENG_G_LEVEL"); err != nil || v != feng.LevelWarn {
+		t.Errorf("Get[feng.Level]: expected warn, but got %v (%v)", v, err)
+	}
+
+	// Test case 2: Parse errors name the key and type
+	if _, err := feng.Get[int8]("FENG_G_UINT8"); err == nil || !strings.Contains(err.Error(), "FENG_G_UINT8 as int8") {
+		t.Errorf("Expected an out of range error, got %v", err)
+	}
+	if _, err := feng.Get[time.Duration]("FENG_G_FLOAT"); err == nil || !strings.Contains(err.Error(), "time.Duration") {
+		t.Errorf("Expected a duration error, got %v", err)
+	}
+	if _, err := feng.Get[feng.Level]("FENG_G_UINT8"); err == nil || !strings.Contains(err.Error(), `unknown log level "255"`) {
+		t.Errorf("Expected an unknown level error, got %v", err)
+	}
+
+	// Test case 3: An unset variable is an error
+	t.Setenv("FENG_G_INT", "")
+	if _, err := feng.Get[int]("FENG_G_INT"); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("Expected a not set error, got %v", err)
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	// Test case 1: A set value wins over the default
+	t.Setenv("FENG_G_WORKERS", "8")
+	if got := feng.GetOr("FENG_G_WORKERS", 4); got != 8 {
+		t.Errorf("Expected 8, but got %d", got)
+	}
+	if got, err := feng.GetOrErr("FENG_G_WORKERS", 4); err != nil || got != 8 {
+		t.Errorf("Expected 8, but got %d (%v)", got, err)
+	}
+
+	// Test case 2: An unset value yields the default
+	t.Setenv("FENG_G_WORKERS", "")
+	if got := feng.GetOr("FENG_G_WORKERS", 4*time.Second); got != 4*time.Second {
+		t.Errorf("Expected 4s, but got %v", got)
+	}
+	if got, err := feng.GetOrErr("FENG_G_WORKERS", 4); err != nil || got != 4 {
+		t.Errorf("Expected 4, but got %d (%v)", got, err)
+	}
+
+	// Test case 3: A parse failure yields the default, or an error from GetOrErr
+	t.Setenv("FENG_G_WORKERS", "many")
+	if got := feng.GetOr("FENG_G_WORKERS", 4); got != 4 {
+		t.Errorf("Expected 4, but got %d", got)
+	}
+	if _, err := feng.GetOrErr("FENG_G_WORKERS", 4); err == nil || !strings.Contains(err.Error(), "FENG_G_WORKERS as int") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+
+	// Test case 4: Booleans share the grammar of GetenvBoolDefault
+	for value, want := range map[string]bool{"on": true, "Yes": true, "disabled": false, "0": false} {
+		t.Setenv("FENG_G_FEATURE", value)
+		if got := feng.GetOr("FENG_G_FEATURE", !want); got != want {
+			t.Errorf("%q: expected %t, but got %t", value, want, got)
+		}
+		if got := feng.GetenvBoolDefault("FENG_G_FEATURE", !want); got != want {
+			t.Errorf("%q: GetenvBoolDefault expected %t, but got %t", value, want, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package feng
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Level is a logging severity read from the environment.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelNames maps the accepted spellings, in lower case, to their Level.
+var levelNames = map[string]Level{
+	"debug":   LevelDebug,
+	"info":    LevelInfo,
+	"warn":    LevelWarn,
+	"warning": LevelWarn,
+	"error":   LevelError,
+	"err":     LevelError,
+}
+
+// String returns the canonical lower-case name of l, which ParseLevel accepts.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// MarshalText implements encoding.TextMarshaler, so Marshal writes levels by name.
+func (l Level) MarshalText() ([]byte, error) {
+	if l < LevelDebug || l > LevelError {
+		return nil, fmt.Errorf("invalid log level %d", int(l))
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLevel, so Get and
+// Unmarshal read levels by name.
+func (l *Level) UnmarshalText(text []byte) error {
+	parsed, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// ParseLevel converts a level name to a Level, case-insensitively.
+//
+// Besides the canonical names, "warning" and "err" are accepted as aliases.
+func ParseLevel(s string) (Level, error) {
+	if l, ok := levelNames[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return l, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// GetenvLogLevel parses the environment variable named by key as a log level, e.g.
+// LOG_LEVEL=debug. An error is returned if the variable is not set or names an
+// unknown level.
+func GetenvLogLevel(key string) (Level, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, fmt.Errorf("environment variable %s not set", key)
+	}
+	l, err := ParseLevel(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid environment variable %s: %w", key, err)
+	}
+	return l, nil
+}
@@ -0,0 +1,96 @@
+package feng_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestGetenvLogLevel(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    feng.Level
+		wantErr bool
+	}{
+		{"debug", feng.LevelDebug, false},
+		{"info", feng.LevelInfo, false},
+		{"warn", feng.LevelWarn, false},
+		{"error", feng.LevelError, false},
+		{"warning", feng.LevelWarn, false},
+		{"err", feng.LevelError, false},
+		{"DeBuG", feng.LevelDebug, false},
+		{"WARNING", feng.LevelWarn, false},
+		{"verbose", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_LOG_LEVEL", tt.value)
+		got, err := feng.GetenvLogLevel("FENG_LOG_LEVEL")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %v, but got %v", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	for _, l := range []feng.Level{feng.LevelDebug, feng.LevelInfo, feng.LevelWarn, feng.LevelError} {
+		got, err := feng.ParseLevel(l.String())
+		if err != nil || got != l {
+			t.Errorf("Round trip of %v failed: got %v (%v)", l, got, err)
+		}
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	type config struct {
+		Level  feng.Level            `env:"FENG_LT_LEVEL"`
+		Levels []feng.Level          `env:"FENG_LT_LEVELS"`
+		ByPkg  map[string]feng.Level `env:"FENG_LT_BY_PKG"`
+	}
+
+	// Test case 1: Levels are marshaled by name
+	in := config{
+		Level:  feng.LevelWarn,
+		Levels: []feng.Level{feng.LevelDebug, feng.LevelError},
+		ByPkg:  map[string]feng.Level{"db": feng.LevelInfo},
+	}
+	got, err := feng.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+	expected := map[string]string{
+		"FENG_LT_LEVEL":  "warn",
+		"FENG_LT_LEVELS": "debug,error",
+		"FENG_LT_BY_PKG": "db=info",
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	// Test case 2: Unmarshal reads names and aliases
+	t.Setenv("FENG_LT_LEVEL", " Warning ")
+	t.Setenv("FENG_LT_LEVELS", "debug, err")
+	t.Setenv("FENG_LT_BY_PKG", "db=info")
+	var out config
+	if err := feng.Unmarshal(&out); err != nil {
+		t.Fatalf("Unmarshal returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Expected %+v, but got %+v", in, out)
+	}
+
+	// Test case 3: Unknown names and invalid levels
+	t.Setenv("FENG_LT_LEVEL", "verbose")
+	if err := feng.Unmarshal(&out); err == nil || !strings.Contains(err.Error(), "unknown log level") {
+		t.Errorf("Expected an unknown level error, got %v", err)
+	}
+	if _, err := feng.Marshal(config{Level: feng.Level(16)}); err == nil {
+		t.Error("Expected an error for an invalid level")
+	}
+}
@@ -0,0 +1,185 @@
+package feng
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// loadOptions holds the settings applied by Option values.
+type loadOptions struct {
+	files       []string
+	overwrite   bool
+	optional    bool
+	interpolate bool
+	strict      bool
+}
+
+// Option configures LoadWith.
+type Option func(*loadOptions)
+
+// WithFiles adds env files to load, in order. Later files override earlier ones.
+// If no files are given, ".env" is loaded.
+func WithFiles(filenames ...string) Option {
+	return func(o *loadOptions) {
+		o.files = append(o.files, filenames...)
+	}
+}
+
+// WithOverwrite controls whether loaded values replace variables that are already
+// set in the environment. It defaults to true, matching Load. When it is false,
+// interpolated references also resolve to the kept environment value, so the loaded
+// values are consistent with what ends up in the environment.
+func WithOverwrite(overwrite bool) Option {
+	return func(o *loadOptions) {
+		o.overwrite = overwrite
+	}
+}
+
+// WithOptional makes missing files be skipped instead of reported as an error.
+func WithOptional(optional bool) Option {
+	return func(o *loadOptions) {
+		o.optional = optional
+	}
+}
+
+// WithInterpolation enables expansion of `$VAR` and `${VAR}` references in values,
+// as described for LoadExpand.
+func WithInterpolation(interpolate bool) Option {
+	return func(o *loadOptions) {
+		o.interpolate = interpolate
+	}
+}
+
+// WithStrictInterpolation enables interpolation and makes any unresolved reference
+// fail the load with an *ExpandError, as described for LoadExpandStrict.
+func WithStrictInterpolation(strict bool) Option {
+	return func(o *loadOptions) {
+		o.strict = strict
+		if strict {
+			o.interpolate = true
+		}
+	}
+}
+
+// LoadWith reads env files and sets the environment variables they define, configured
+// by opts.
+//
+// It is the single entry point behind Load, LoadExpand and LoadExpandStrict. Nothing is
+// set unless every file has been read and, in strict mode, every reference resolved.
+func LoadWith(opts ...Option) error {
+	o := loadOptions{overwrite: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.files) == 0 {
+		o.files = []string{".env"}
+	}
+
+	envMap := make(map[string]string)
+	lookup := func(name string) (string, bool) {
+		// without overwrite, an already set variable keeps its value, so references
+		// must resolve to it rather than to the value from the file
+		if !o.overwrite {
+			if v, ok := os.LookupEnv(name); ok {
+				return v, true
+			}
+		}
+		if v, ok := envMap[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	var unresolved []UnresolvedRef
+	for _, filename := range o.files {
+		lines, err := readLines(filename)
+		if err != nil {
+			if o.optional && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("failed to read env file %s: %w", filename, err)
+		}
+		for _, l := range lines {
+			value := l.value
+			if o.interpolate && l.quote != '\'' && !l.heredoc {
+				var missing []string
+				value, missing = expandVars(value, lookup)
+				for _, name := range missing {
+					unresolved = append(unresolved, UnresolvedRef{Name: name, File: filename, Line: l.line})
+				}
+			}
+			envMap[l.key] = value
+		}
+	}
+
+	if o.strict && len(unresolved) > 0 {
+		return &ExpandError{Refs: unresolved}
+	}
+
+	if !o.overwrite {
+		for k := range envMap {
+			if _, ok := os.LookupEnv(k); ok {
+				delete(envMap, k)
+			}
+		}
+	}
+
+	if err := SetenvMap(envMap); err != nil {
+		return fmt.Errorf("failed to set environment variables: %w", err)
+	}
+	return nil
+}
+
+var (
+	loadOnceMu   sync.Mutex
+	loadOnceDone = make(map[string]bool)
+)
+
+// LoadOnce is like Load but does nothing if the same set of files has already been
+// loaded successfully, so it can safely be called from several init paths.
+//
+// Calls are keyed by the set of file names, regardless of their order. A failed load
+// is not remembered and may be retried. Calls are serialized, so concurrent callers
+// wait for the first one to finish. ResetLoadOnce forgets every completed load.
+func LoadOnce(filenames ...string) error {
+	files := append([]string(nil), filenames...)
+	if len(files) == 0 {
+		files = []string{".env"}
+	}
+	sort.Strings(files)
+	key := strings.Join(files, "\x00")
+
+	loadOnceMu.Lock()
+	defer loadOnceMu.Unlock()
+	if loadOnceDone[key] {
+		return nil
+	}
+	if err := Load(filenames...); err != nil {
+		return err
+	}
+	loadOnceDone[key] = true
+	return nil
+}
+
+// ResetLoadOnce forgets the loads recorded by LoadOnce, so the next call reads its
+// files again. It is intended for tests.
+func ResetLoadOnce() {
+	loadOnceMu.Lock()
+	defer loadOnceMu.Unlock()
+	loadOnceDone = make(map[string]bool)
+}
+
+// readLines opens filename and parses its assignments in source order.
+func readLines(filename string) ([]envLine, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseLines(f)
+}
@@ -0,0 +1,127 @@
+package feng_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestLoadWith(t *testing.T) {
+	t.Setenv("FENG_LW_HOST", "preset")
+	t.Setenv("FENG_LW_PORT", "")
+	t.Setenv("FENG_LW_ADDR", "")
+	os.Unsetenv("FENG_LW_PORT")
+	os.Unsetenv("FENG_LW_ADDR")
+
+	filename := writeTempEnv(t, "FENG_LW_HOST=fromfile\nFENG_LW_PORT=8080\nFENG_LW_ADDR=${FENG_LW_HOST}:${FENG_LW_PORT}\n")
+	missing := filepath.Join(t.TempDir(), "missing.env")
+
+	// Test case 1: Missing optional file, no overwrite, interpolation
+	err := feng.LoadWith(
+		feng.WithFiles(missing, filename),
+		feng.WithOptional(true),
+		feng.WithOverwrite(false),
+		feng.WithInterpolation(true),
+	)
+	if err != nil {
+		t.Fatalf("LoadWith returned an error: %v", err)
+	}
+	if got := os.Getenv("FENG_LW_HOST"); got != "preset" {
+		t.Errorf("Expected preset value to be kept, but got %s", got)
+	}
+	if got := os.Getenv("FENG_LW_PORT"); got != "8080" {
+		t.Errorf("Expected FENG_LW_PORT=8080, but got %s", got)
+	}
+	if got := os.Getenv("FENG_LW_ADDR"); got != "preset:8080" {
+		t.Errorf("Expected FENG_LW_ADDR to use the kept value, preset:8080, but got %s", got)
+	}
+
+	// Test case 2: Overwrite replaces the preset value
+	if err := feng.LoadWith(feng.WithFiles(filename), feng.WithOverwrite(true)); err != nil {
+		t.Fatalf("LoadWith returned an error: %v", err)
+	}
+	if got := os.Getenv("FENG_LW_HOST"); got != "fromfile" {
+		t.Errorf("Expected overwritten value, but got %s", got)
+	}
+
+	// Test case 3: A missing file is an error unless optional
+	if err := feng.LoadWith(feng.WithFiles(missing)); err == nil {
+		t.Error("Expected an error for a missing required file")
+	}
+}
+
+func TestLoadOnce(t *testing.T) {
+	feng.ResetLoadOnce()
+	t.Cleanup(feng.ResetLoadOnce)
+	t.Setenv("FENG_ONCE", "")
+
+	filename := writeTempEnv(t, "FENG_ONCE=first\n")
+	other := writeTempEnv(t, "FENG_ONCE_OTHER=1\n")
+	t.Setenv("FENG_ONCE_OTHER", "")
+
+	// Test case 1: The first call loads the files
+	if err := feng.LoadOnce(filename, other); err != nil {
+		t.Fatalf("LoadOnce returned an error: %v", err)
+	}
+	if got := os.Getenv("FENG_ONCE"); got != "first" {
+		t.Fatalf("Expected FENG_ONCE=first, but got %s", got)
+	}
+
+	// Test case 2: Later calls with the same files are no-ops, in any order
+	if err := os.WriteFile(filename, []byte("FENG_ONCE=second\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := feng.LoadOnce(other, filename); err != nil {
+			t.Fatalf("LoadOnce returned an error: %v", err)
+		}
+	}
+	if got := os.Getenv("FENG_ONCE"); got != "first" {
+		t.Errorf("Expected the file to be read only once, but got FENG_ONCE=%s", got)
+	}
+
+	// Test case 3: A failed load is not remembered
+	missing := filepath.Join(t.TempDir(), "missing.env")
+	if err := feng.LoadOnce(missing); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if err := os.WriteFile(missing, []byte("FENG_ONCE=late\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := feng.LoadOnce(missing); err != nil || os.Getenv("FENG_ONCE") != "late" {
+		t.Errorf("Expected the retry to load the file, got %v and FENG_ONCE=%s", err, os.Getenv("FENG_ONCE"))
+	}
+
+	// Test case 4: ResetLoadOnce re-enables loading
+	feng.ResetLoadOnce()
+	if err := feng.LoadOnce(filename, other); err != nil {
+		t.Fatalf("LoadOnce returned an error: %v", err)
+	}
+	if got := os.Getenv("FENG_ONCE"); got != "second" {
+		t.Errorf("Expected FENG_ONCE=second after reset, but got %s", got)
+	}
+}
+
+func TestLoadWithHeredocNotInterpolated(t *testing.T) {
+	t.Setenv("FENG_HD_PRICE", "")
+	t.Setenv("FENG_HD_HOST", "")
+	t.Setenv("FENG_HD_ADDR", "")
+	filename := writeTempEnv(t, "FENG_HD_HOST=example.com\nFENG_HD_PRICE<<EOF\nprice $5 and ${UNSET} at $FENG_HD_HOST\nEOF\nFENG_HD_ADDR=${FENG_HD_HOST}\n")
+
+	if err := feng.LoadWith(feng.WithFiles(filename), feng.WithInterpolation(true)); err != nil {
+		t.Fatalf("LoadWith returned an error: %v", err)
+	}
+	if got, want := os.Getenv("FENG_HD_PRICE"), "price $5 and ${UNSET} at $FENG_HD_HOST"; got != want {
+		t.Errorf("Expected the heredoc body verbatim %q, but got %q", want, got)
+	}
+	if got := os.Getenv("FENG_HD_ADDR"); got != "example.com" {
+		t.Errorf("Expected other values to be interpolated, but got %q", got)
+	}
+
+	// Strict mode does not report references inside heredocs
+	if err := feng.LoadWith(feng.WithFiles(filename), feng.WithStrictInterpolation(true)); err != nil {
+		t.Errorf("Expected no unresolved references, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package feng
+
+import "io"
+
+// OrderedMap is a set of environment variables that remembers insertion order.
+//
+// It is returned by ParseOrdered so tools that rewrite env files can keep the keys in
+// the order they appeared in the source. The zero value is an empty map ready to use.
+type OrderedMap struct {
+	keys   []string
+	values map[string]string
+}
+
+// NewOrderedMap returns an empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: make(map[string]string)}
+}
+
+// Get returns the value of key and whether it is present.
+func (m *OrderedMap) Get(key string) (string, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set assigns value to key. A new key is appended at the end; an existing key keeps
+// its position.
+func (m *OrderedMap) Set(key, value string) {
+	if m.values == nil {
+		m.values = make(map[string]string)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Delete removes key, if present.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in order. The returned slice is a copy.
+func (m *OrderedMap) Keys() []string {
+	return append([]string(nil), m.keys...)
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Range calls fn for each key-value pair in order, stopping early if fn returns false.
+func (m *OrderedMap) Range(fn func(key, value string) bool) {
+	for _, k := range m.keys {
+		if !fn(k, m.values[k]) {
+			return
+		}
+	}
+}
+
+// ParseOrdered parses env file content from r, keeping the order in which keys first
+// appear. A key defined more than once keeps its first position and its last value.
+func ParseOrdered(r io.Reader) (*OrderedMap, error) {
+	lines, err := parseLines(r)
+	if err != nil {
+		return nil, err
+	}
+	m := NewOrderedMap()
+	for _, l := range lines {
+		m.Set(l.key, l.value)
+	}
+	return m, nil
+}
+
+// WriteEnvFile writes m to filename as an env file, keeping m's key order. Entries are
+// formatted as described for WriteEnvFileMap, which accepts the same options.
+func (m *OrderedMap) WriteEnvFile(filename string, opts ...WriteOption) error {
+	return writeEnvFile(filename, m.keys, m.values, opts)
+}
+
+// WriteEnvTo streams m to w in env file format, keeping m's key order, like the
+// package-level WriteEnvTo.
+func (m *OrderedMap) WriteEnvTo(w io.Writer, opts ...WriteOption) error {
+	return writeEnv(w, m.keys, m.values, opts)
+}
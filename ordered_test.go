@@ -0,0 +1,51 @@
+package feng_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestParseOrdered(t *testing.T) {
+	content := "# settings\nZETA=1\nALPHA=2\nMID=3\nZETA=4\n"
+
+	m, err := feng.ParseOrdered(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseOrdered returned an error: %v", err)
+	}
+
+	// Test case 1: Iteration order matches the source
+	if want := []string{"ZETA", "ALPHA", "MID"}; !reflect.DeepEqual(m.Keys(), want) {
+		t.Errorf("Expected keys %v, but got %v", want, m.Keys())
+	}
+	if v, _ := m.Get("ZETA"); v != "4" {
+		t.Errorf("Expected the last value of ZETA, but got %s", v)
+	}
+
+	// Test case 2: Set keeps existing positions and appends new keys
+	m.Set("ALPHA", "changed")
+	m.Set("NEW", "5")
+	m.Delete("MID")
+	var got []string
+	m.Range(func(k, v string) bool {
+		got = append(got, k+"="+v)
+		return true
+	})
+	if want := []string{"ZETA=4", "ALPHA=changed", "NEW=5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, but got %v", want, got)
+	}
+
+	// Test case 3: Writing keeps the order
+	filename := filepath.Join(t.TempDir(), ".env")
+	if err := m.WriteEnvFile(filename); err != nil {
+		t.Fatalf("WriteEnvFile returned an error: %v", err)
+	}
+	data, _ := os.ReadFile(filename)
+	if want := "ZETA=4\nALPHA=changed\nNEW=5\n"; string(data) != want {
+		t.Errorf("Expected %q, but got %q", want, data)
+	}
+}
@@ -0,0 +1,438 @@
+package feng
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// envLine is a single assignment read from an env file.
+type envLine struct {
+	key   string
+	value string
+	// line is the 1-based line number the assignment was read from.
+	line int
+	// endLine is the 1-based line number the assignment ends on; it differs from line
+	// only for heredocs, where it is the closing delimiter line.
+	endLine int
+	// quote is the quote character that surrounded the value, or 0 if unquoted.
+	quote byte
+	// heredoc reports whether the value was read from a heredoc body.
+	heredoc bool
+	// section is the name of the enclosing [section], when sections are parsed.
+	section string
+	// trailingSpace reports whether the source line ended in whitespace.
+	trailingSpace bool
+}
+
+// KeyCase selects how ReadEnvFileWithOptions normalizes key casing.
+type KeyCase int
+
+const (
+	// KeyCasePreserve keeps keys as written.
+	KeyCasePreserve KeyCase = iota
+	// KeyCaseUpper converts keys to upper case.
+	KeyCaseUpper
+	// KeyCaseLower converts keys to lower case.
+	KeyCaseLower
+)
+
+// ReadOptions controls the normalizations applied by ReadEnvFileWithOptions.
+//
+// The zero value reads files exactly like ReadEnvFile.
+type ReadOptions struct {
+	// StripBOM removes a UTF-8 byte order mark from the start of the first line.
+	StripBOM bool
+	// KeyCase converts every key to the given case.
+	KeyCase KeyCase
+	// RejectControlChars fails parsing when a value contains a NUL byte or another
+	// ASCII control character. Tabs and the line breaks of heredoc values are allowed.
+	RejectControlChars bool
+	// CommentChars lists the characters that start a comment, either at the beginning
+	// of a line or after an unquoted value. An empty string means "#".
+	CommentChars string
+
+	// sections makes `[name]` lines start a new section instead of being ignored.
+	sections bool
+}
+
+// utf8BOM is the byte order mark some editors write at the start of UTF-8 files.
+const utf8BOM = "\uFEFF"
+
+// ReadEnvFileWithOptions reads the contents of a .env file into a map, applying opts.
+//
+// It is meant for files produced by heterogeneous tools, for example files saved with
+// a byte order mark or using inconsistent key casing. As with ReadEnvFile, unquoted
+// values are always trimmed of surrounding whitespace while quoted values are kept
+// verbatim.
+func ReadEnvFileWithOptions(filename string, opts ReadOptions) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, err := parseLinesWithOptions(f, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	envMap := make(map[string]string)
+	for _, l := range lines {
+		envMap[l.key] = l.value
+	}
+	return envMap, nil
+}
+
+// sectionRegx matches an INI-style section header, e.g. `[database]`.
+var sectionRegx = regexp.MustCompile(`\A\[([^\[\]]*[^\[\]\s][^\[\]]*)\]\s*(?:[#;].*)?\z`)
+
+// heredocRegx matches the opening line of a heredoc value, e.g. `KEY<<EOF`.
+var heredocRegx = regexp.MustCompile(`\A([\w\.]+)<<([A-Za-z_][A-Za-z0-9_]*)\z`)
+
+// lineRegexFor returns a variant of lineRegx in which any of chars starts a comment.
+func lineRegexFor(chars string) *regexp.Regexp {
+	var b strings.Builder
+	for _, r := range chars {
+		if strings.ContainsRune(`\]^-[`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	class := b.String()
+	return regexp.MustCompile(`\A\s*(?:export\s+)?([\w\.]+)(?:\s*=\s*|:\s+?)('(?:\'|[^'])*'|"(?:\"|[^"])*"|[^` + class + `\n]+)?\s*(?:\s*[` + class + `].*)?\z`)
+}
+
+// parseLines reads env file content from r and returns its assignments in source order.
+//
+// Empty lines and comment lines are skipped, and an optional leading `export ` is
+// removed before the line is matched. Lines that do not look like an assignment are
+// ignored.
+//
+// Values follow the quoting grammar described in the package documentation; a value
+// that opens a quote without closing it is an error.
+//
+// A line of the form `KEY<<DELIM` starts a heredoc: the following lines are taken
+// verbatim, joined with newlines, until a line consisting only of DELIM. Like
+// single-quoted values, heredoc bodies are never interpolated. A heredoc
+// without its closing line is an error.
+func parseLines(r io.Reader) ([]envLine, error) {
+	return parseLinesWithOptions(r, ReadOptions{})
+}
+
+// parseLinesWithOptions is parseLines with the normalizations described by opts.
+func parseLinesWithOptions(r io.Reader, opts ReadOptions) ([]envLine, error) {
+	var lines []envLine
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanLines)
+	lineNo := 0
+	section := ""
+	lineRe := lineRegx
+	comments := "#"
+	if opts.CommentChars != "" && opts.CommentChars != comments {
+		comments = opts.CommentChars
+		lineRe = lineRegexFor(comments)
+	}
+	for scanner.Scan() {
+		lineNo++
+		text := scanner.Text()
+		if lineNo == 1 && opts.StripBOM {
+			text = strings.TrimPrefix(text, utf8BOM)
+		}
+		l := strings.TrimSpace(text)
+		// skip empty lines and comment line
+		if l == "" || strings.IndexByte(comments, l[0]) >= 0 {
+			continue
+		}
+		if opts.sections && l[0] == '[' {
+			m := sectionRegx.FindStringSubmatch(l)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: malformed section header %q", lineNo, l)
+			}
+			section = strings.TrimSpace(m[1])
+			continue
+		}
+		// trim export start
+		l = strings.TrimPrefix(l, "export ")
+		var key, value string
+		var quote byte
+		heredoc := false
+		startLine := lineNo
+		if m := heredocRegx.FindStringSubmatch(l); m != nil {
+			// capture the following lines verbatim until the delimiter line
+			key = m[1]
+			var body []string
+			terminated := false
+			for scanner.Scan() {
+				lineNo++
+				if strings.TrimSpace(scanner.Text()) == m[2] {
+					terminated = true
+					break
+				}
+				body = append(body, scanner.Text())
+			}
+			if !terminated {
+				return nil, fmt.Errorf("line %d: unterminated heredoc for %s: missing closing %s", startLine, key, m[2])
+			}
+			value = strings.Join(body, "\n")
+			heredoc = true
+		} else {
+			parts := lineRe.FindStringSubmatch(l)
+			if len(parts) == 0 {
+				continue
+			}
+			raw := strings.TrimSpace(parts[2])
+			key = removeQuotes(strings.TrimSpace(parts[1]))
+			if isUnterminatedQuote(raw) {
+				return nil, fmt.Errorf("line %d: unterminated quoted value for %s", lineNo, key)
+			}
+			value = removeQuotes(raw)
+			if value != raw {
+				quote = raw[0]
+			}
+		}
+		switch opts.KeyCase {
+		case KeyCaseUpper:
+			key = strings.ToUpper(key)
+		case KeyCaseLower:
+			key = strings.ToLower(key)
+		}
+		if opts.RejectControlChars {
+			if i := strings.IndexFunc(value, isDisallowedControl); i >= 0 {
+				return nil, fmt.Errorf("line %d: value of %s contains control character %U", startLine, key, value[i])
+			}
+		}
+		lines = append(lines, envLine{
+			key:           key,
+			value:         value,
+			line:          startLine,
+			endLine:       lineNo,
+			quote:         quote,
+			heredoc:       heredoc,
+			section:       section,
+			trailingSpace: text != strings.TrimRight(text, " \t"),
+		})
+	}
+
+	return lines, scanner.Err()
+}
+
+// Entry is a single assignment read from an env file, together with its location.
+type Entry struct {
+	Key   string
+	Value string
+	// Line is the 1-based line number the entry was defined on.
+	Line int
+	// EndLine is the 1-based line number the entry ends on. It equals Line except for
+	// heredoc values, where it is the line holding the closing delimiter.
+	EndLine int
+	// Quoted reports whether the value was surrounded by single or double quotes.
+	Quoted bool
+}
+
+// ParseWithPositions parses env file content from r and returns its entries in source
+// order, keeping the line number of each definition.
+//
+// Unlike ReadEnvFile, repeated keys are all returned, which makes it suitable for
+// linters and other editor tooling.
+func ParseWithPositions(r io.Reader) ([]Entry, error) {
+	lines, err := parseLines(r)
+	if err != nil {
+		return nil, err
+	}
+	return toEntries(lines), nil
+}
+
+// toEntries converts parsed lines to their exported form.
+func toEntries(lines []envLine) []Entry {
+	entries := make([]Entry, 0, len(lines))
+	for _, l := range lines {
+		entries = append(entries, Entry{Key: l.key, Value: l.value, Line: l.line, EndLine: l.endLine, Quoted: l.quote != 0})
+	}
+	return entries
+}
+
+// isUnterminatedQuote reports whether raw opens a quoted value that is never closed,
+// such as `"abc`.
+func isUnterminatedQuote(raw string) bool {
+	return raw != "" && (raw[0] == '"' || raw[0] == '\'') && strings.IndexByte(raw[1:], raw[0]) < 0
+}
+
+// isDisallowedControl reports whether r is an ASCII control character other than tab
+// or the newline separating heredoc lines.
+func isDisallowedControl(r rune) bool {
+	return (r < 0x20 && r != '\t' && r != '\n') || r == 0x7f
+}
+
+// Duplicate reports a key that is defined more than once.
+type Duplicate struct {
+	Key string
+	// Lines holds the line number of every definition, in source order.
+	Lines []int
+}
+
+// DuplicateKeyError is returned by ParseStrict when a key is defined more than once.
+type DuplicateKeyError struct {
+	Duplicates []Duplicate
+}
+
+// Error lists each duplicated key and the lines defining it.
+func (e *DuplicateKeyError) Error() string {
+	parts := make([]string, 0, len(e.Duplicates))
+	for _, d := range e.Duplicates {
+		lines := make([]string, 0, len(d.Lines))
+		for _, l := range d.Lines {
+			lines = append(lines, strconv.Itoa(l))
+		}
+		parts = append(parts, fmt.Sprintf("%s (lines %s)", d.Key, strings.Join(lines, ", ")))
+	}
+	return "duplicate keys: " + strings.Join(parts, "; ")
+}
+
+// FindDuplicates reports the keys defined more than once among entries, in the order
+// of their first definition.
+//
+// Together with ParseWithPositions it lets callers keep the usual last-wins behavior
+// while still surfacing accidental redefinitions.
+func FindDuplicates(entries []Entry) []Duplicate {
+	lines := make(map[string][]int)
+	var order []string
+	for _, e := range entries {
+		if _, seen := lines[e.Key]; !seen {
+			order = append(order, e.Key)
+		}
+		lines[e.Key] = append(lines[e.Key], e.Line)
+	}
+
+	var dups []Duplicate
+	for _, k := range order {
+		if len(lines[k]) > 1 {
+			dups = append(dups, Duplicate{Key: k, Lines: lines[k]})
+		}
+	}
+	return dups
+}
+
+// ParseStrict parses env file content from r into a map, failing with a
+// *DuplicateKeyError if any key is defined more than once.
+//
+// Values containing a NUL byte or another control character, other than tabs and
+// heredoc line breaks, are rejected as well, with an error naming the key and line.
+func ParseStrict(r io.Reader) (map[string]string, error) {
+	lines, err := parseLinesWithOptions(r, ReadOptions{RejectControlChars: true})
+	if err != nil {
+		return nil, err
+	}
+	entries := toEntries(lines)
+	if dups := FindDuplicates(entries); len(dups) > 0 {
+		return nil, &DuplicateKeyError{Duplicates: dups}
+	}
+
+	envMap := make(map[string]string, len(entries))
+	for _, e := range entries {
+		envMap[e.Key] = e.Value
+	}
+	return envMap, nil
+}
+
+// ParseSections parses env file content from r that is divided into INI-style sections.
+//
+// A `[name]` line starts a section and the assignments that follow are collected under
+// name, parsed exactly as by ReadEnvFile. Assignments before the first header belong to
+// the "" section. A line starting with `[` that is not a well-formed header, such as
+// `[db` or `[]`, is an error.
+func ParseSections(r io.Reader) (map[string]map[string]string, error) {
+	lines, err := parseLinesWithOptions(r, ReadOptions{sections: true})
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make(map[string]map[string]string)
+	for _, l := range lines {
+		if sections[l.section] == nil {
+			sections[l.section] = make(map[string]string)
+		}
+		sections[l.section][l.key] = l.value
+	}
+	return sections, nil
+}
+
+// Warning is a non-fatal finding reported by ParseWithWarnings.
+type Warning struct {
+	// Line is the 1-based line number the warning refers to.
+	Line int
+	// Key is the variable defined on that line.
+	Key string
+	// Message describes the finding, e.g. "line has trailing whitespace".
+	Message string
+}
+
+// warnOptions holds the settings applied by WarnOption values.
+type warnOptions struct {
+	deprecated []*regexp.Regexp
+}
+
+// WarnOption configures the checks run by ParseWithWarnings.
+type WarnOption func(*warnOptions)
+
+// WithDeprecatedKeys makes ParseWithWarnings report every key matching one of
+// patterns as deprecated.
+func WithDeprecatedKeys(patterns ...*regexp.Regexp) WarnOption {
+	return func(o *warnOptions) {
+		o.deprecated = append(o.deprecated, patterns...)
+	}
+}
+
+// String formats the warning as "line N: KEY: message".
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s: %s", w.Line, w.Key, w.Message)
+}
+
+// ParseWithWarnings parses env file content from r into a map, like ReadEnvFile, and
+// also reports advisory findings meant for linters.
+//
+// A warning is produced for a line ending in whitespace, an unquoted value that is a
+// JSON object or array, a key that shadows an earlier definition, and a key matching a
+// pattern given with WithDeprecatedKeys. Warnings are returned in source order.
+// Syntax errors, such as an unterminated quote, are still returned as an error.
+func ParseWithWarnings(r io.Reader, opts ...WarnOption) (map[string]string, []Warning, error) {
+	var o warnOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	lines, err := parseLines(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envMap := make(map[string]string, len(lines))
+	defined := make(map[string]int, len(lines))
+	var warnings []Warning
+	for _, l := range lines {
+		if l.trailingSpace {
+			warnings = append(warnings, Warning{Line: l.line, Key: l.key, Message: "line has trailing whitespace"})
+		}
+		if v := l.value; l.quote == 0 && v != "" && (v[0] == '{' || v[0] == '[') && json.Valid([]byte(v)) {
+			warnings = append(warnings, Warning{Line: l.line, Key: l.key, Message: "value looks like unquoted JSON"})
+		}
+		if prev, ok := defined[l.key]; ok {
+			warnings = append(warnings, Warning{Line: l.line, Key: l.key, Message: fmt.Sprintf("shadows the definition on line %d", prev)})
+		}
+		for _, re := range o.deprecated {
+			if re.MatchString(l.key) {
+				warnings = append(warnings, Warning{Line: l.line, Key: l.key, Message: fmt.Sprintf("key matches deprecated pattern %s", re)})
+				break
+			}
+		}
+		defined[l.key] = l.line
+		envMap[l.key] = l.value
+	}
+	return envMap, warnings, nil
+}
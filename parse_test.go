@@ -0,0 +1,294 @@
+package feng_test
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestReadEnvFileWithOptions(t *testing.T) {
+	// Test case 1: A BOM-prefixed file
+	t.Run("BOM-prefixed file", func(t *testing.T) {
+		filename := writeTempEnv(t, "\uFEFFKEY1=VALUE1\nKEY2=VALUE2   \n")
+
+		got, err := feng.ReadEnvFile(filename)
+		if err != nil {
+			t.Fatalf("ReadEnvFile returned an error: %v", err)
+		}
+		if _, ok := got["KEY1"]; ok {
+			t.Errorf("Expected the BOM line to be dropped without StripBOM, got %v", got)
+		}
+
+		got, err = feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{StripBOM: true})
+		if err != nil {
+			t.Fatalf("ReadEnvFileWithOptions returned an error: %v", err)
+		}
+		expected := map[string]string{"KEY1": "VALUE1", "KEY2": "VALUE2"}
+		if !compareMap(expected, got) {
+			t.Errorf("Expected %v, but got %v", expected, got)
+		}
+	})
+
+	// Test case 2: Key casing options
+	t.Run("Key casing", func(t *testing.T) {
+		filename := writeTempEnv(t, "Mixed_Key=1\nlower_key=2\n")
+
+		got, err := feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{KeyCase: feng.KeyCaseUpper})
+		if err != nil {
+			t.Fatalf("ReadEnvFileWithOptions returned an error: %v", err)
+		}
+		if !compareMap(map[string]string{"MIXED_KEY": "1", "LOWER_KEY": "2"}, got) {
+			t.Errorf("Unexpected upper-cased result: %v", got)
+		}
+
+		got, err = feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{KeyCase: feng.KeyCaseLower})
+		if err != nil {
+			t.Fatalf("ReadEnvFileWithOptions returned an error: %v", err)
+		}
+		if !compareMap(map[string]string{"mixed_key": "1", "lower_key": "2"}, got) {
+			t.Errorf("Unexpected lower-cased result: %v", got)
+		}
+	})
+
+	// Test case 3: Comment characters
+	t.Run("Comment characters", func(t *testing.T) {
+		filename := writeTempEnv(t, "; ini comment\n# hash comment\nA=1 ; trailing\nB=2 # trailing\nC=\"x;y\"\nD='#z'\n")
+
+		got, err := feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{})
+		if err != nil {
+			t.Fatalf("ReadEnvFileWithOptions returned an error: %v", err)
+		}
+		expected := map[string]string{"A": "1 ; trailing", "B": "2", "C": "x;y", "D": "#z"}
+		if !compareMap(expected, got) {
+			t.Errorf("Expected %v with # comments, but got %v", expected, got)
+		}
+
+		got, err = feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{CommentChars: ";"})
+		if err != nil {
+			t.Fatalf("ReadEnvFileWithOptions returned an error: %v", err)
+		}
+		expected = map[string]string{"A": "1", "B": "2 # trailing", "C": "x;y", "D": "#z"}
+		if _, ok := got["#"]; ok || !compareMap(expected, got) {
+			t.Errorf("Expected %v with ; comments, but got %v", expected, got)
+		}
+
+		got, err = feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{CommentChars: "#;"})
+		if err != nil {
+			t.Fatalf("ReadEnvFileWithOptions returned an error: %v", err)
+		}
+		expected = map[string]string{"A": "1", "B": "2", "C": "x;y", "D": "#z"}
+		if !compareMap(expected, got) {
+			t.Errorf("Expected %v with both comment styles, but got %v", expected, got)
+		}
+	})
+}
+
+func TestParseWithPositions(t *testing.T) {
+	content := "# header comment\n\nKEY1=VALUE1\n  # indented comment\nexport KEY2=\"VALUE 2\"\n\n\nKEY3='three' # trailing\nKEY1=again\n"
+
+	got, err := feng.ParseWithPositions(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseWithPositions returned an error: %v", err)
+	}
+
+	expected := []feng.Entry{
+		{Key: "KEY1", Value: "VALUE1", Line: 3, EndLine: 3},
+		{Key: "KEY2", Value: "VALUE 2", Line: 5, EndLine: 5, Quoted: true},
+		{Key: "KEY3", Value: "three", Line: 8, EndLine: 8, Quoted: true},
+		{Key: "KEY1", Value: "again", Line: 9, EndLine: 9},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %+v, but got %+v", expected, got)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	content := "FOO=1\nBAR=2\n# comment\nFOO=3\n"
+
+	// Test case 1: Strict parsing reports the duplicate
+	_, err := feng.ParseStrict(strings.NewReader(content))
+	var dupErr *feng.DuplicateKeyError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("Expected a DuplicateKeyError, got %v", err)
+	}
+	expected := []feng.Duplicate{{Key: "FOO", Lines: []int{1, 4}}}
+	if !reflect.DeepEqual(expected, dupErr.Duplicates) {
+		t.Errorf("Expected %+v, but got %+v", expected, dupErr.Duplicates)
+	}
+
+	// Test case 2: Non-strict parsing keeps last-wins and reports duplicates
+	entries, err := feng.ParseWithPositions(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseWithPositions returned an error: %v", err)
+	}
+	if dups := feng.FindDuplicates(entries); !reflect.DeepEqual(expected, dups) {
+		t.Errorf("Expected %+v, but got %+v", expected, dups)
+	}
+
+	// Test case 3: No duplicates
+	got, err := feng.ParseStrict(strings.NewReader("FOO=1\nBAR=2\n"))
+	if err != nil {
+		t.Fatalf("ParseStrict returned an error: %v", err)
+	}
+	if !compareMap(map[string]string{"FOO": "1", "BAR": "2"}, got) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+}
+
+func TestRejectControlChars(t *testing.T) {
+	// Test case 1: A tab is allowed
+	got, err := feng.ParseStrict(strings.NewReader("TABBED=\"a\tb\"\n"))
+	if err != nil {
+		t.Fatalf("ParseStrict returned an error: %v", err)
+	}
+	if got["TABBED"] != "a\tb" {
+		t.Errorf("Unexpected value: %q", got["TABBED"])
+	}
+
+	// Test case 2: A NUL byte is rejected with the key and line
+	_, err = feng.ParseStrict(strings.NewReader("OK=1\nNULLED=a\x00b\n"))
+	if err == nil || !strings.Contains(err.Error(), "NULLED") || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error naming NULLED on line 2, got %v", err)
+	}
+
+	// Test case 3: The same check is available through ReadEnvFileWithOptions
+	filename := writeTempEnv(t, "NULLED=a\x00b\n")
+	if _, err := feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{RejectControlChars: true}); err == nil {
+		t.Error("Expected an error for a NUL byte")
+	}
+	if _, err := feng.ReadEnvFileWithOptions(filename, feng.ReadOptions{}); err != nil {
+		t.Errorf("Expected no error without RejectControlChars, got %v", err)
+	}
+}
+
+func TestParseHeredoc(t *testing.T) {
+	// Test case 1: A basic heredoc
+	content := "BEFORE=1\nCERT<<EOF\n-----BEGIN-----\n  indented line\n-----END-----\nEOF\nAFTER=2\n"
+	entries, err := feng.ParseWithPositions(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseWithPositions returned an error: %v", err)
+	}
+	expected := []feng.Entry{
+		{Key: "BEFORE", Value: "1", Line: 1, EndLine: 1},
+		{Key: "CERT", Value: "-----BEGIN-----\n  indented line\n-----END-----", Line: 2, EndLine: 6},
+		{Key: "AFTER", Value: "2", Line: 7, EndLine: 7},
+	}
+	if !reflect.DeepEqual(expected, entries) {
+		t.Errorf("Expected %+v, but got %+v", expected, entries)
+	}
+
+	// Test case 2: The delimiter as a substring does not terminate
+	content = "BLOB<<EOF\nnot EOF yet\nEOFX\nEOF\n"
+	got, err := feng.ParseStrict(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseStrict returned an error: %v", err)
+	}
+	if got["BLOB"] != "not EOF yet\nEOFX" {
+		t.Errorf("Unexpected value: %q", got["BLOB"])
+	}
+
+	// Test case 3: An unterminated heredoc is an error
+	_, err = feng.ParseWithPositions(strings.NewReader("BLOB<<EOF\nline1\nline2\n"))
+	if err == nil || !strings.Contains(err.Error(), "unterminated heredoc") {
+		t.Errorf("Expected an unterminated heredoc error, got %v", err)
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	// Test case 1: Multiple sections and the default section
+	content := "GLOBAL=1\n\n[api]\nPORT=8080\nHOST=\"0.0.0.0\"\n\n[ worker ] # background jobs\nPORT=9090\n"
+	got, err := feng.ParseSections(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseSections returned an error: %v", err)
+	}
+	expected := map[string]map[string]string{
+		"":       {"GLOBAL": "1"},
+		"api":    {"PORT": "8080", "HOST": "0.0.0.0"},
+		"worker": {"PORT": "9090"},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	// Test case 2: Malformed headers
+	for _, header := range []string{"[api", "[]", "[a]b]"} {
+		_, err := feng.ParseSections(strings.NewReader("A=1\n" + header + "\nB=2\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("%q: expected a malformed header error on line 2, got %v", header, err)
+		}
+	}
+}
+
+func TestParseQuotedValues(t *testing.T) {
+	// Test case 1: Values containing the other quote type
+	content := `A="it's fine"
+B='say "hi"'
+C="  padded  " # comment
+D='#not a comment'
+`
+	got, err := feng.ParseWithPositions(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseWithPositions returned an error: %v", err)
+	}
+	expected := []feng.Entry{
+		{Key: "A", Value: "it's fine", Line: 1, EndLine: 1, Quoted: true},
+		{Key: "B", Value: `say "hi"`, Line: 2, EndLine: 2, Quoted: true},
+		{Key: "C", Value: "  padded  ", Line: 3, EndLine: 3, Quoted: true},
+		{Key: "D", Value: "#not a comment", Line: 4, EndLine: 4, Quoted: true},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %+v, but got %+v", expected, got)
+	}
+
+	// Test case 2: Unterminated quotes are reported
+	for _, content := range []string{"A=1\nC=\"unterminated\n", "C='unterminated\n"} {
+		_, err := feng.ParseWithPositions(strings.NewReader(content))
+		if err == nil || !strings.Contains(err.Error(), "unterminated quoted value for C") {
+			t.Errorf("%q: expected an unterminated quote error, got %v", content, err)
+		}
+	}
+}
+
+func TestParseWithWarnings(t *testing.T) {
+	content := "HOST=localhost  \nPORT=8080\nLIMITS={\"cpu\":2}\nQUOTED='{\"a\":1}'\nHOST=example.com\n"
+
+	// Test case 1: Trailing whitespace, unquoted JSON and a shadowed key
+	got, warnings, err := feng.ParseWithWarnings(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseWithWarnings returned an error: %v", err)
+	}
+	if got["HOST"] != "example.com" || got["PORT"] != "8080" {
+		t.Errorf("Unexpected map: %v", got)
+	}
+	expected := []feng.Warning{
+		{Line: 1, Key: "HOST", Message: "line has trailing whitespace"},
+		{Line: 3, Key: "LIMITS", Message: "value looks like unquoted JSON"},
+		{Line: 5, Key: "HOST", Message: "shadows the definition on line 1"},
+	}
+	if !reflect.DeepEqual(expected, warnings) {
+		t.Errorf("Expected %v, but got %v", expected, warnings)
+	}
+
+	// Test case 2: Syntax errors are still fatal
+	if _, _, err := feng.ParseWithWarnings(strings.NewReader("A=\"open\n")); err == nil {
+		t.Error("Expected an error for an unterminated quote")
+	}
+
+	// Test case 3: Keys matching a deprecated pattern
+	_, warnings, err = feng.ParseWithWarnings(strings.NewReader("OLD_HOST=a\nHOST=b\nLEGACY_PORT=1\n"),
+		feng.WithDeprecatedKeys(regexp.MustCompile(`^OLD_`), regexp.MustCompile(`^LEGACY_`)))
+	if err != nil {
+		t.Fatalf("ParseWithWarnings returned an error: %v", err)
+	}
+	expected = []feng.Warning{
+		{Line: 1, Key: "OLD_HOST", Message: "key matches deprecated pattern ^OLD_"},
+		{Line: 3, Key: "LEGACY_PORT", Message: "key matches deprecated pattern ^LEGACY_"},
+	}
+	if !reflect.DeepEqual(expected, warnings) {
+		t.Errorf("Expected %v, but got %v", expected, warnings)
+	}
+}
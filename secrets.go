@@ -0,0 +1,73 @@
+package feng
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// secretKeyReplacer maps the characters common in secret file names to underscores.
+var secretKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// secretsOptions holds the settings applied by SecretsOption values.
+type secretsOptions struct {
+	verbatim bool
+}
+
+// SecretsOption configures LoadSecretsDir.
+type SecretsOption func(*secretsOptions)
+
+// WithVerbatimNames makes LoadSecretsDir use file names as keys exactly as they are,
+// without changing case or replacing dots and dashes.
+func WithVerbatimNames(enabled bool) SecretsOption {
+	return func(o *secretsOptions) {
+		o.verbatim = enabled
+	}
+}
+
+// LoadSecretsDir reads a directory of secret files, as mounted by Kubernetes and
+// Docker, into a map.
+//
+// Each regular file becomes one entry: the key is the file name in upper case with
+// dots and dashes replaced by underscores, so "db-password" becomes DB_PASSWORD, or
+// the file name as is with WithVerbatimNames. The value is the file's content with
+// surrounding whitespace trimmed. Symbolic links are followed. Subdirectories and
+// hidden entries, including the "..data" links Kubernetes maintains, are ignored. The
+// environment is not modified; pass the result to SetenvMap to apply it.
+func LoadSecretsDir(dir string, opts ...SecretsOption) (map[string]string, error) {
+	var o secretsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s: %w", path, err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s: %w", path, err)
+		}
+		key := e.Name()
+		if !o.verbatim {
+			key = strings.ToUpper(secretKeyReplacer.Replace(key))
+		}
+		secrets[key] = strings.TrimSpace(string(data))
+	}
+	return secrets, nil
+}
@@ -0,0 +1,71 @@
+package feng_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestLoadSecretsDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Lay the directory out like a Kubernetes secret volume
+	data := filepath.Join(dir, "..2024_01_01")
+	if err := os.MkdirAll(filepath.Join(data, "nested"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(data, "api.token"), []byte("tok-123\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(data, filepath.Join(dir, "..data")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "api.token"), filepath.Join(dir, "api.token")); err != nil {
+		t.Fatal(err)
+	}
+	write("db-password", "  hunter2 \n")
+	write(".hidden", "ignored")
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join("subdir", "inner"), "ignored")
+
+	// Test case 1: Regular and symlinked files are loaded, directories are ignored
+	got, err := feng.LoadSecretsDir(dir)
+	if err != nil {
+		t.Fatalf("LoadSecretsDir returned an error: %v", err)
+	}
+	expected := map[string]string{
+		"API_TOKEN":   "tok-123",
+		"DB_PASSWORD": "hunter2",
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	// Test case 2: File names kept verbatim
+	got, err = feng.LoadSecretsDir(dir, feng.WithVerbatimNames(true))
+	if err != nil {
+		t.Fatalf("LoadSecretsDir returned an error: %v", err)
+	}
+	expected = map[string]string{
+		"api.token":   "tok-123",
+		"db-password": "hunter2",
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	// Test case 3: A missing directory
+	if _, err := feng.LoadSecretsDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,133 @@
+package feng
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version of the form MAJOR.MINOR.PATCH[-PRERELEASE].
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseVersion parses s in the `x.y.z[-pre]` form. A leading "v" is accepted.
+func ParseVersion(s string) (Version, error) {
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	core, pre, hasPre := strings.Cut(core, "-")
+	if hasPre && !validPrerelease(pre) {
+		return Version{}, fmt.Errorf("invalid version %q: malformed prerelease %q", s, pre)
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || p == "" || p[0] == '+' || (len(p) > 1 && p[0] == '0') {
+			return Version{}, fmt.Errorf("invalid version %q: bad component %q", s, p)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Prerelease: pre}, nil
+}
+
+// validPrerelease reports whether pre is a dot separated list of non-empty
+// alphanumeric identifiers, hyphens allowed.
+func validPrerelease(pre string) bool {
+	for _, id := range strings.Split(pre, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String formats v back into its textual form.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether v sorts before, equal to or after o.
+//
+// Precedence follows semantic versioning: numeric components are compared first, and
+// a version with a prerelease sorts before the same version without one.
+func (v Version) Compare(o Version) int {
+	for _, d := range [...]int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(o.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePrereleaseID(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePrereleaseID compares two prerelease identifiers: numeric identifiers compare
+// numerically and sort before alphanumeric ones, which compare lexically.
+func comparePrereleaseID(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(na - nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// sign returns -1, 0 or +1 according to the sign of n.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// GetenvSemver parses the environment variable named by key as a semantic version,
+// e.g. MIN_CLIENT=1.4.0.
+func GetenvSemver(key string) (Version, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return Version{}, fmt.Errorf("environment variable %s not set", key)
+	}
+	v, err := ParseVersion(value)
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid environment variable %s: %w", key, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,55 @@
+package feng_test
+
+import (
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestGetenvSemver(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    feng.Version
+		wantErr bool
+	}{
+		{"1.4.0", feng.Version{Major: 1, Minor: 4, Patch: 0}, false},
+		{"v2.0.10", feng.Version{Major: 2, Minor: 0, Patch: 10}, false},
+		{"1.4.0-rc.1", feng.Version{Major: 1, Minor: 4, Patch: 0, Prerelease: "rc.1"}, false},
+		{"1.4", feng.Version{}, true},
+		{"1.x.0", feng.Version{}, true},
+		{"01.2.3", feng.Version{}, true},
+		{"1.2.3-", feng.Version{}, true},
+		{"", feng.Version{}, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("FENG_MIN_CLIENT", tt.value)
+		got, err := feng.GetenvSemver("FENG_MIN_CLIENT")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %+v, but got %+v", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0", "1.0.1", "1.2.0", "2.0.0"}
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := feng.ParseVersion(ordered[i])
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) returned an error: %v", ordered[i], err)
+		}
+		b, err := feng.ParseVersion(ordered[i+1])
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) returned an error: %v", ordered[i+1], err)
+		}
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("Expected %s < %s", a, b)
+		}
+		if a.Compare(a) != 0 {
+			t.Errorf("Expected %s to equal itself", a)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package feng
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// checkTrimmed returns an error if the value of the environment variable named by key
+// has leading or trailing whitespace.
+func checkTrimmed(key string) error {
+	value := os.Getenv(key)
+	if value != strings.TrimSpace(value) {
+		return fmt.Errorf("environment variable %s has surrounding whitespace: %q", key, value)
+	}
+	return nil
+}
+
+// GetenvIntStrict is like GetenvInt but rejects values with surrounding whitespace.
+func GetenvIntStrict(key string) (int, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvInt(key)
+}
+
+// GetenvInt8Strict is like GetenvInt8 but rejects values with surrounding whitespace.
+func GetenvInt8Strict(key string) (int8, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvInt8(key)
+}
+
+// GetenvInt16Strict is like GetenvInt16 but rejects values with surrounding whitespace.
+func GetenvInt16Strict(key string) (int16, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvInt16(key)
+}
+
+// GetenvInt32Strict is like GetenvInt32 but rejects values with surrounding whitespace.
+func GetenvInt32Strict(key string) (int32, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvInt32(key)
+}
+
+// GetenvInt64Strict is like GetenvInt64 but rejects values with surrounding whitespace.
+func GetenvInt64Strict(key string) (int64, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvInt64(key)
+}
+
+// GetenvUint8Strict is like GetenvUint8 but rejects values with surrounding whitespace.
+func GetenvUint8Strict(key string) (uint8, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvUint8(key)
+}
+
+// GetenvUint16Strict is like GetenvUint16 but rejects values with surrounding whitespace.
+func GetenvUint16Strict(key string) (uint16, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvUint16(key)
+}
+
+// GetenvUint32Strict is like GetenvUint32 but rejects values with surrounding whitespace.
+func GetenvUint32Strict(key string) (uint32, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvUint32(key)
+}
+
+// GetenvUint64Strict is like GetenvUint64 but rejects values with surrounding whitespace.
+func GetenvUint64Strict(key string) (uint64, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvUint64(key)
+}
+
+// GetenvFloat32Strict is like GetenvFloat32 but rejects values with surrounding whitespace.
+func GetenvFloat32Strict(key string) (float32, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvFloat32(key)
+}
+
+// GetenvFloat64Strict is like GetenvFloat64 but rejects values with surrounding whitespace.
+func GetenvFloat64Strict(key string) (float64, error) {
+	if err := checkTrimmed(key); err != nil {
+		return 0, err
+	}
+	return GetenvFloat64(key)
+}
@@ -0,0 +1,61 @@
+package feng_test
+
+import (
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestNumericGettersTrimWhitespace(t *testing.T) {
+	t.Setenv("FENG_PADDED", " 42 ")
+
+	getters := map[string]func(string) (float64, error){
+		"Int":     func(k string) (float64, error) { v, err := feng.GetenvInt(k); return float64(v), err },
+		"Int8":    func(k string) (float64, error) { v, err := feng.GetenvInt8(k); return float64(v), err },
+		"Int16":   func(k string) (float64, error) { v, err := feng.GetenvInt16(k); return float64(v), err },
+		"Int32":   func(k string) (float64, error) { v, err := feng.GetenvInt32(k); return float64(v), err },
+		"Int64":   func(k string) (float64, error) { v, err := feng.GetenvInt64(k); return float64(v), err },
+		"Uint8":   func(k string) (float64, error) { v, err := feng.GetenvUint8(k); return float64(v), err },
+		"Uint16":  func(k string) (float64, error) { v, err := feng.GetenvUint16(k); return float64(v), err },
+		"Uint32":  func(k string) (float64, error) { v, err := feng.GetenvUint32(k); return float64(v), err },
+		"Uint64":  func(k string) (float64, error) { v, err := feng.GetenvUint64(k); return float64(v), err },
+		"Float32": func(k string) (float64, error) { v, err := feng.GetenvFloat32(k); return float64(v), err },
+		"Float64": feng.GetenvFloat64,
+	}
+	strict := map[string]func(string) (float64, error){
+		"Int":     func(k string) (float64, error) { v, err := feng.GetenvIntStrict(k); return float64(v), err },
+		"Int8":    func(k string) (float64, error) { v, err := feng.GetenvInt8Strict(k); return float64(v), err },
+		"Int16":   func(k string) (float64, error) { v, err := feng.GetenvInt16Strict(k); return float64(v), err },
+		"Int32":   func(k string) (float64, error) { v, err := feng.GetenvInt32Strict(k); return float64(v), err },
+		"Int64":   func(k string) (float64, error) { v, err := feng.GetenvInt64Strict(k); return float64(v), err },
+		"Uint8":   func(k string) (float64, error) { v, err := feng.GetenvUint8Strict(k); return float64(v), err },
+		"Uint16":  func(k string) (float64, error) { v, err := feng.GetenvUint16Strict(k); return float64(v), err },
+		"Uint32":  func(k string) (float64, error) { v, err := feng.GetenvUint32Strict(k); return float64(v), err },
+		"Uint64":  func(k string) (float64, error) { v, err := feng.GetenvUint64Strict(k); return float64(v), err },
+		"Float32": func(k string) (float64, error) { v, err := feng.GetenvFloat32Strict(k); return float64(v), err },
+		"Float64": feng.GetenvFloat64Strict,
+	}
+
+	// Test case 1: Default getters trim the value
+	for name, get := range getters {
+		v, err := get("FENG_PADDED")
+		if err != nil || v != 42 {
+			t.Errorf("%s: expected 42, got %v (%v)", name, v, err)
+		}
+	}
+
+	// Test case 2: Strict getters reject the padded value
+	for name, get := range strict {
+		if _, err := get("FENG_PADDED"); err == nil {
+			t.Errorf("%sStrict: expected an error for a padded value", name)
+		}
+	}
+
+	// Test case 3: Strict getters accept a clean value
+	t.Setenv("FENG_PADDED", "42")
+	for name, get := range strict {
+		if v, err := get("FENG_PADDED"); err != nil || v != 42 {
+			t.Errorf("%sStrict: expected 42, got %v (%v)", name, v, err)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package feng
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Marshal converts the `env`-tagged fields of a struct into a map of environment
+// variables.
+//
+// Strings, booleans, integers, unsigned integers, floats, time.Duration and time.Time
+// (formatted as RFC3339) are supported, as are slices of these, which are joined with
+// commas, and maps with string keys, which are written as `key=value` pairs in sorted
+// key order joined with commas. Commas inside items are escaped as `\,`, so the result
+// reads back with Unmarshal. Other types implementing encoding.TextMarshaler, such as
+// Level, are written with MarshalText. Untagged struct fields are walked recursively;
+// other untagged fields are ignored. An error is returned for a tagged field of an
+// unsupported type.
+func Marshal(v interface{}) (map[string]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("marshal: nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("marshal: expected struct, got %s", rv.Kind())
+	}
+
+	envMap := make(map[string]string)
+	if err := marshalFields(rv, envMap); err != nil {
+		return nil, err
+	}
+	return envMap, nil
+}
+
+// marshalFields formats the tagged fields of rv into envMap.
+func marshalFields(rv reflect.Value, envMap map[string]string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fv := rv.Field(i)
+
+		name := envTagName(field)
+		if name == "" {
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := marshalFields(fv, envMap); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		value, err := formatValue(fv)
+		if err != nil {
+			return fmt.Errorf("marshal: field %s (%s): %w", field.Name, name, err)
+		}
+		envMap[name] = value
+	}
+	return nil
+}
+
+// envTagName returns the variable name from the `env` tag of field, ignoring any
+// options after a comma.
+func envTagName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+	return name
+}
+
+// formatValue renders fv in the textual form read back by the getters.
+func formatValue(fv reflect.Value) (string, error) {
+	switch fv.Type() {
+	case durationType:
+		return time.Duration(fv.Int()).String(), nil
+	case timeType:
+		return fv.Interface().(time.Time).Format(time.RFC3339), nil
+	}
+	if m, ok := asInterface(fv).(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(fv.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'g', -1, 64), nil
+	case reflect.Slice:
+		items := make([]string, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			item, err := formatValue(fv.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items = append(items, strings.ReplaceAll(item, ",", `\,`))
+		}
+		return strings.Join(items, ","), nil
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := make([]string, 0, fv.Len())
+		values := make(map[string]string, fv.Len())
+		iter := fv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if strings.Contains(k, "=") {
+				return "", fmt.Errorf("map key %q contains \"=\"", k)
+			}
+			v, err := formatValue(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			keys = append(keys, k)
+			values[k] = v
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, strings.ReplaceAll(k+"="+values[k], ",", `\,`))
+		}
+		return strings.Join(pairs, ","), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", fv.Type())
+}
+
+// SetenvStruct sets the environment variables described by the `env`-tagged fields of v.
+//
+// It is the inverse of reading configuration from the environment and is useful when
+// spawning child processes that expect their settings as variables. The update is
+// atomic: if v cannot be marshaled nothing is changed, and if setting any variable
+// fails the variables already set are restored to their previous state.
+func SetenvStruct(v interface{}) error {
+	envMap, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	type previous struct {
+		value string
+		set   bool
+	}
+	applied := make(map[string]previous, len(envMap))
+	for _, k := range sortedKeys(envMap) {
+		value, set := os.LookupEnv(k)
+		if err := os.Setenv(k, envMap[k]); err != nil {
+			for rk, p := range applied {
+				if p.set {
+					os.Setenv(rk, p.value)
+				} else {
+					os.Unsetenv(rk)
+				}
+			}
+			return fmt.Errorf("failed to set environment variable %s: %w", k, err)
+		}
+		applied[k] = previous{value: value, set: set}
+	}
+	return nil
+}
+
+// MarshalRedacted behaves like Marshal but masks the value of every key containing
+// SECRET, PASSWORD, TOKEN or KEY (case-insensitive) with "****", making the result
+// safe to log. Note that GetenvMapJSONRedacted does not mask KEY by default. Use
+// MarshalRedactedWith to choose a different pattern.
+//
+// Only the returned map is redacted; v is never modified.
+func MarshalRedacted(v interface{}) (map[string]string, error) {
+	return MarshalRedactedWith(v, defaultSensitiveKeyPattern)
+}
+
+// MarshalRedactedWith behaves like MarshalRedacted but uses sensitive to decide which
+// keys to mask.
+func MarshalRedactedWith(v interface{}, sensitive *regexp.Regexp) (map[string]string, error) {
+	envMap, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	for k := range envMap {
+		if sensitive.MatchString(k) {
+			envMap[k] = redactedValue
+		}
+	}
+	return envMap, nil
+}
+
+// Unmarshal populates the `env`-tagged fields of the struct pointed to by v from the
+// environment.
+//
+// It accepts the types supported by Marshal and parses them like the corresponding
+// getters: numbers are trimmed and integers follow the base prefix rules, booleans
+// use the extended grammar of GetenvBoolDefault, durations use time.ParseDuration and
+// times RFC3339. Slices are read as comma separated lists in which `\,` stands for a
+// literal comma. Maps with string keys, such as map[string]string or map[string]int,
+// are read as comma separated `key=value` pairs, e.g. LABELS=team=core,tier=1; an
+// empty value yields an empty map and a pair without "=" is an error. Types
+// implementing encoding.TextUnmarshaler, such as Level, are read with UnmarshalText.
+// Untagged struct fields are walked recursively.
+//
+// Fields whose variable is not set are left unchanged, unless the tag carries the
+// required option, as in `env:"HOST,required"`, which makes an unset variable an
+// error. This only asks for the variable to be present: HOST= satisfies it. Use
+// ValidateStruct with `validate:"required"` to reject empty or zero values as well.
+//
+// Two fields bound to the same variable are an error, since only one of them could
+// meaningfully be set.
+func Unmarshal(v interface{}) error {
+	return UnmarshalPrefix("", v)
+}
+
+// UnmarshalPrefix is like Unmarshal but binds only the variables starting with prefix,
+// which is prepended to every `env` tag. This lets one environment configure several
+// structs by namespace: with prefix "DB_", a field tagged `env:"HOST"` is read from
+// DB_HOST and variables outside the namespace are ignored.
+func UnmarshalPrefix(prefix string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("unmarshal: expected non-nil pointer to struct")
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal: expected struct, got %s", rv.Kind())
+	}
+	return unmarshalFields(rv, prefix, make(map[string]string))
+}
+
+// unmarshalFields sets the tagged fields of rv from the environment. seen maps each
+// variable already bound to the field reading it.
+func unmarshalFields(rv reflect.Value, prefix string, seen map[string]string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fv := rv.Field(i)
+
+		name := envTagName(field)
+		if name == "" {
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := unmarshalFields(fv, prefix, seen); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		name = prefix + name
+
+		if other, dup := seen[name]; dup {
+			return fmt.Errorf("unmarshal: fields %s and %s are both bound to %s", other, field.Name, name)
+		}
+		seen[name] = field.Name
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if hasEnvOption(field, "required") {
+				return fmt.Errorf("unmarshal: field %s: environment variable %s not set", field.Name, name)
+			}
+			continue
+		}
+		if err := parseValue(value, fv); err != nil {
+			return fmt.Errorf("unmarshal: field %s (%s): %w", field.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// hasEnvOption reports whether the `env` tag of field lists opt after the name.
+func hasEnvOption(field reflect.StructField, opt string) bool {
+	options := strings.Split(field.Tag.Get("env"), ",")[1:]
+	for _, o := range options {
+		if strings.TrimSpace(o) == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// asInterface returns a pointer to fv when fv is addressable, and fv itself otherwise,
+// so that methods with either receiver are found by a type assertion.
+func asInterface(fv reflect.Value) interface{} {
+	if fv.CanAddr() {
+		return fv.Addr().Interface()
+	}
+	return fv.Interface()
+}
+
+// parseValue parses s into fv, the inverse of formatValue.
+func parseValue(s string, fv reflect.Value) error {
+	switch fv.Type() {
+	case durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if u, ok := asInterface(fv).(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(strings.TrimSpace(s)))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := parseBoolExtended(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if s != "" {
+			parts = splitEscaped(s, ",")
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := parseValue(strings.TrimSpace(p), slice.Index(i)); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		fv.Set(slice)
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", fv.Type())
+		}
+		pairs, err := parseKeyValues(s, ",", "=")
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(fv.Type(), len(pairs))
+		for _, k := range sortedKeys(pairs) {
+			elem := reflect.New(fv.Type().Elem()).Elem()
+			if err := parseValue(pairs[k], elem); err != nil {
+				return fmt.Errorf("key %s: %w", k, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(fv.Type().Key()), elem)
+		}
+		fv.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+// parseKeyValues parses a list of key/value pairs separated by pairSep, with kvSep
+// between each key and value, e.g. "a=1,b=2". Keys and values are trimmed, pairSep
+// preceded by a backslash stands for a literal separator and empty items are skipped.
+// A pair without kvSep or with an empty key is an error.
+func parseKeyValues(s, pairSep, kvSep string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return pairs, nil
+	}
+	for _, item := range splitEscaped(s, pairSep) {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, kvSep)
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("malformed pair %q: expected key%svalue", strings.TrimSpace(item), kvSep)
+		}
+		pairs[k] = strings.TrimSpace(v)
+	}
+	return pairs, nil
+}
@@ -0,0 +1,335 @@
+package feng_test
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nosusume/feng"
+)
+
+type marshalConfig struct {
+	Host     string        `env:"FENG_M_HOST"`
+	Port     int           `env:"FENG_M_PORT"`
+	Debug    bool          `env:"FENG_M_DEBUG"`
+	Ratio    float64       `env:"FENG_M_RATIO"`
+	MaxConn  uint16        `env:"FENG_M_MAX_CONN"`
+	Timeout  time.Duration `env:"FENG_M_TIMEOUT"`
+	Tags     []string      `env:"FENG_M_TAGS"`
+	internal string
+	Nested   struct {
+		Name string `env:"FENG_M_NESTED_NAME"`
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	cfg := marshalConfig{
+		Host:    "localhost",
+		Port:    8080,
+		Debug:   true,
+		Ratio:   0.25,
+		MaxConn: 100,
+		Timeout: 90 * time.Second,
+		Tags:    []string{"a", "b,c"},
+	}
+	cfg.Nested.Name = "inner"
+
+	got, err := feng.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+	expected := map[string]string{
+		"FENG_M_HOST":        "localhost",
+		"FENG_M_PORT":        "8080",
+		"FENG_M_DEBUG":       "true",
+		"FENG_M_RATIO":       "0.25",
+		"FENG_M_MAX_CONN":    "100",
+		"FENG_M_TIMEOUT":     "1m30s",
+		"FENG_M_TAGS":        `a,b\,c`,
+		"FENG_M_NESTED_NAME": "inner",
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
+
+func TestSetenvStruct(t *testing.T) {
+	t.Setenv("FENG_M_HOST", "original")
+	t.Setenv("FENG_M_PORT", "")
+	os.Unsetenv("FENG_M_PORT")
+
+	// Test case 1: Every field is set
+	t.Run("Sets every field", func(t *testing.T) {
+		t.Setenv("FENG_M_DEBUG", "")
+		t.Setenv("FENG_M_TIMEOUT", "")
+		cfg := struct {
+			Debug   bool          `env:"FENG_M_DEBUG"`
+			Timeout time.Duration `env:"FENG_M_TIMEOUT"`
+		}{Debug: true, Timeout: time.Second}
+		if err := feng.SetenvStruct(cfg); err != nil {
+			t.Fatalf("SetenvStruct returned an error: %v", err)
+		}
+		if os.Getenv("FENG_M_DEBUG") != "true" || os.Getenv("FENG_M_TIMEOUT") != "1s" {
+			t.Errorf("Unexpected environment: %s %s", os.Getenv("FENG_M_DEBUG"), os.Getenv("FENG_M_TIMEOUT"))
+		}
+	})
+
+	// Test case 2: A format error leaves the environment unchanged
+	t.Run("Format error", func(t *testing.T) {
+		cfg := struct {
+			Host string   `env:"FENG_M_HOST"`
+			Bad  chan int `env:"FENG_M_BAD"`
+		}{Host: "changed"}
+		if err := feng.SetenvStruct(cfg); err == nil {
+			t.Fatal("Expected an error for an unsupported field type")
+		}
+		if got := os.Getenv("FENG_M_HOST"); got != "original" {
+			t.Errorf("Expected FENG_M_HOST to be unchanged, but got %s", got)
+		}
+	})
+
+	// Test case 3: A failing Setenv rolls back earlier changes
+	t.Run("Rollback", func(t *testing.T) {
+		cfg := struct {
+			Host string `env:"FENG_M_HOST"`
+			Port int    `env:"FENG_M_PORT"`
+			Bad  string `env:"FENG_M_Z=BAD"`
+		}{Host: "changed", Port: 1}
+		if err := feng.SetenvStruct(cfg); err == nil {
+			t.Fatal("Expected an error for an invalid variable name")
+		}
+		if got := os.Getenv("FENG_M_HOST"); got != "original" {
+			t.Errorf("Expected FENG_M_HOST to be restored, but got %s", got)
+		}
+		if _, ok := os.LookupEnv("FENG_M_PORT"); ok {
+			t.Error("Expected FENG_M_PORT to be unset again")
+		}
+	})
+}
+
+func TestMarshalRedacted(t *testing.T) {
+	cfg := struct {
+		User     string `env:"DB_USER"`
+		Password string `env:"DB_PASSWORD"`
+		APIKey   string `env:"API_KEY"`
+		Token    string `env:"auth_token"`
+	}{User: "app", Password: "hunter2", APIKey: "abc", Token: "xyz"}
+
+	// Test case 1: Sensitive keys are masked, others untouched
+	got, err := feng.MarshalRedacted(&cfg)
+	if err != nil {
+		t.Fatalf("MarshalRedacted returned an error: %v", err)
+	}
+	expected := map[string]string{
+		"DB_USER":     "app",
+		"DB_PASSWORD": "****",
+		"API_KEY":     "****",
+		"auth_token":  "****",
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+	if cfg.Password != "hunter2" {
+		t.Error("Expected the source struct to be left unmodified")
+	}
+
+	// Test case 2: A custom pattern
+	got, err = feng.MarshalRedactedWith(&cfg, regexp.MustCompile(`^DB_`))
+	if err != nil {
+		t.Fatalf("MarshalRedactedWith returned an error: %v", err)
+	}
+	if got["DB_USER"] != "****" || got["API_KEY"] != "abc" {
+		t.Errorf("Unexpected result: %v", got)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type config struct {
+		Host    string            `env:"FENG_U_HOST"`
+		Port    int               `env:"FENG_U_PORT"`
+		Timeout time.Duration     `env:"FENG_U_TIMEOUT"`
+		Tags    []string          `env:"FENG_U_TAGS"`
+		Labels  map[string]string `env:"FENG_U_LABELS"`
+		Limits  map[string]int    `env:"FENG_U_LIMITS"`
+		Unset   string            `env:"FENG_U_UNSET"`
+	}
+
+	// Test case 1: Scalars, slices and map fields
+	t.Run("Map fields", func(t *testing.T) {
+		t.Setenv("FENG_U_HOST", "localhost")
+		t.Setenv("FENG_U_PORT", " 8080 ")
+		t.Setenv("FENG_U_TIMEOUT", "5s")
+		t.Setenv("FENG_U_TAGS", `a, b\,c`)
+		t.Setenv("FENG_U_LABELS", "team=core, tier = gold,url=http://x?a=b")
+		t.Setenv("FENG_U_LIMITS", "cpu=2,mem=0x100")
+
+		cfg := config{Unset: "default"}
+		if err := feng.Unmarshal(&cfg); err != nil {
+			t.Fatalf("Unmarshal returned an error: %v", err)
+		}
+		if cfg.Host != "localhost" || cfg.Port != 8080 || cfg.Timeout != 5*time.Second || cfg.Unset != "default" {
+			t.Errorf("Unexpected scalar fields: %+v", cfg)
+		}
+		if len(cfg.Tags) != 2 || cfg.Tags[0] != "a" || cfg.Tags[1] != "b,c" {
+			t.Errorf("Unexpected tags: %q", cfg.Tags)
+		}
+		expected := map[string]string{"team": "core", "tier": "gold", "url": "http://x?a=b"}
+		if !compareMap(expected, cfg.Labels) {
+			t.Errorf("Expected labels %v, but got %v", expected, cfg.Labels)
+		}
+		if len(cfg.Limits) != 2 || cfg.Limits["cpu"] != 2 || cfg.Limits["mem"] != 256 {
+			t.Errorf("Unexpected limits: %v", cfg.Limits)
+		}
+	})
+
+	// Test case 2: An empty value yields an empty map
+	t.Run("Empty map", func(t *testing.T) {
+		t.Setenv("FENG_U_LABELS", "")
+		cfg := config{Labels: map[string]string{"old": "1"}}
+		if err := feng.Unmarshal(&cfg); err != nil {
+			t.Fatalf("Unmarshal returned an error: %v", err)
+		}
+		if cfg.Labels == nil || len(cfg.Labels) != 0 {
+			t.Errorf("Expected an empty map, but got %v", cfg.Labels)
+		}
+	})
+
+	// Test case 3: Malformed pairs and values
+	t.Run("Malformed pair", func(t *testing.T) {
+		t.Setenv("FENG_U_LABELS", "team=core,oops")
+		var cfg config
+		err := feng.Unmarshal(&cfg)
+		if err == nil || !strings.Contains(err.Error(), `malformed pair "oops"`) {
+			t.Errorf("Expected a malformed pair error, got %v", err)
+		}
+
+		t.Setenv("FENG_U_LABELS", "")
+		t.Setenv("FENG_U_LIMITS", "cpu=two")
+		if err := feng.Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), "FENG_U_LIMITS") {
+			t.Errorf("Expected an error naming FENG_U_LIMITS, got %v", err)
+		}
+	})
+
+	// Test case 4: A non-pointer argument
+	if err := feng.Unmarshal(config{}); err == nil {
+		t.Error("Expected an error for a non-pointer argument")
+	}
+
+	// Test case 5: The required option asks for the variable to be set, empty or not
+	t.Run("Required", func(t *testing.T) {
+		var cfg struct {
+			Token string `env:"FENG_U_TOKEN,required"`
+		}
+		err := feng.Unmarshal(&cfg)
+		if err == nil || !strings.Contains(err.Error(), "FENG_U_TOKEN not set") {
+			t.Errorf("Expected a required field error, got %v", err)
+		}
+
+		t.Setenv("FENG_U_TOKEN", "")
+		if err := feng.Unmarshal(&cfg); err != nil {
+			t.Errorf("Expected an empty required variable to be accepted, got %v", err)
+		}
+	})
+
+	// Test case 6: Two fields bound to the same variable
+	t.Run("Collision", func(t *testing.T) {
+		var cfg struct {
+			Host  string `env:"FENG_U_HOST"`
+			Again string `env:"FENG_U_HOST"`
+		}
+		err := feng.Unmarshal(&cfg)
+		if err == nil || !strings.Contains(err.Error(), "both bound to FENG_U_HOST") {
+			t.Errorf("Expected a collision error, got %v", err)
+		}
+	})
+}
+
+func TestUnmarshalPrefix(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT"`
+	}
+	type config struct {
+		Primary database
+		Name    string `env:"NAME"`
+	}
+
+	t.Setenv("FENG_DB_HOST", "db.internal")
+	t.Setenv("FENG_DB_PORT", "5432")
+	t.Setenv("FENG_CACHE_HOST", "cache.internal")
+	t.Setenv("HOST", "unrelated")
+	t.Setenv("NAME", "unrelated")
+
+	// Test case 1: A namespaced sub-struct ignores unrelated variables
+	var cfg config
+	if err := feng.UnmarshalPrefix("FENG_DB_", &cfg); err != nil {
+		t.Fatalf("UnmarshalPrefix returned an error: %v", err)
+	}
+	if cfg.Primary.Host != "db.internal" || cfg.Primary.Port != 5432 || cfg.Name != "" {
+		t.Errorf("Unexpected result: %+v", cfg)
+	}
+
+	// Test case 2: A missing required field
+	var cache database
+	err := feng.UnmarshalPrefix("FENG_QUEUE_", &cache)
+	if err == nil || !strings.Contains(err.Error(), "FENG_QUEUE_HOST not set") {
+		t.Errorf("Expected a required field error, got %v", err)
+	}
+
+	// Test case 3: Two fields bound to the same variable
+	var dup struct {
+		Host  string `env:"HOST"`
+		Again string `env:"HOST"`
+	}
+	if err := feng.UnmarshalPrefix("FENG_DB_", &dup); err == nil || !strings.Contains(err.Error(), "both bound to FENG_DB_HOST") {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+}
+
+func TestMarshalMapRoundTrip(t *testing.T) {
+	type config struct {
+		Labels map[string]string `env:"FENG_RT_LABELS"`
+		Limits map[string]int    `env:"FENG_RT_LIMITS"`
+	}
+	in := config{
+		Labels: map[string]string{"tier": "gold", "team": "core", "list": "a,b", "url": "http://x?a=b"},
+		Limits: map[string]int{"mem": 256, "cpu": 2},
+	}
+
+	// Test case 1: Maps are written as sorted key=value pairs
+	got, err := feng.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+	expected := map[string]string{
+		"FENG_RT_LABELS": `list=a\,b,team=core,tier=gold,url=http://x?a=b`,
+		"FENG_RT_LIMITS": "cpu=2,mem=256",
+	}
+	if !compareMap(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	// Test case 2: The struct round-trips through the environment
+	if err := feng.SetenvStruct(in); err != nil {
+		t.Fatalf("SetenvStruct returned an error: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Unsetenv("FENG_RT_LABELS")
+		os.Unsetenv("FENG_RT_LIMITS")
+	})
+	var out config
+	if err := feng.Unmarshal(&out); err != nil {
+		t.Fatalf("Unmarshal returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Expected %+v, but got %+v", in, out)
+	}
+
+	// Test case 3: Keys containing "=" cannot be represented
+	if _, err := feng.Marshal(config{Labels: map[string]string{"a=b": "c"}}); err == nil {
+		t.Error("Expected an error for a key containing =")
+	}
+}
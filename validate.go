@@ -0,0 +1,142 @@
+package feng
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ValidationErrors collects every rule violation found by ValidateStruct.
+type ValidationErrors []error
+
+// Error joins all violations into a single message.
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(ve))
+	for _, err := range ve {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateStruct checks the `validate` tags of a struct populated from the environment.
+//
+// The supported rules are:
+//   - required: the field must not hold its zero value. Unlike the `env:",required"`
+//     option of Unmarshal, which only asks for the variable to be set, an empty string
+//     or a zero number fails this rule.
+//   - min=N / max=N: numeric fields are compared by value, strings and slices by length.
+//   - oneof=a b c: the field's formatted value must be one of the space separated options.
+//
+// Rules are comma separated, e.g. `validate:"min=1,max=65535"`. Fields are reported by
+// their `env` tag when present, otherwise by their Go name. All violations are returned
+// together as a ValidationErrors value.
+func ValidateStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return errors.New("validate: nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected struct, got %s", rv.Kind())
+	}
+
+	var errs ValidationErrors
+	validateFields(rv, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateFields walks the exported fields of rv and appends any violation to errs.
+func validateFields(rv reflect.Value, errs *ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fv := rv.Field(i)
+
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			if fv.Kind() == reflect.Struct {
+				validateFields(fv, errs)
+			}
+			continue
+		}
+
+		name := field.Name
+		if envName := strings.Split(field.Tag.Get("env"), ",")[0]; envName != "" {
+			name = envName
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			rule = strings.TrimSpace(rule)
+			if rule == "" {
+				continue
+			}
+			if err := validateRule(fv, rule); err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+}
+
+// validateRule applies a single rule such as "required" or "min=1" to fv.
+func validateRule(fv reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if fv.IsZero() {
+			return errors.New("is required")
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s rule %q", name, arg)
+		}
+		n, ok := measure(fv)
+		if !ok {
+			return fmt.Errorf("%s rule not supported for %s", name, fv.Kind())
+		}
+		if name == "min" && n < limit {
+			return fmt.Errorf("must be at least %s", arg)
+		}
+		if name == "max" && n > limit {
+			return fmt.Errorf("must be at most %s", arg)
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		value := fmt.Sprint(fv.Interface())
+		for _, opt := range options {
+			if value == opt {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s], got %q", strings.Join(options, " "), value)
+	default:
+		return fmt.Errorf("unknown validation rule %q", name)
+	}
+	return nil
+}
+
+// measure returns the value compared by min and max rules: the number itself
+// for numeric kinds and the length for strings, slices and maps.
+func measure(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), true
+	}
+	return 0, false
+}
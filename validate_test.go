@@ -0,0 +1,75 @@
+package feng_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+type validateConfig struct {
+	Host string `env:"HOST" validate:"required"`
+	Port int    `env:"PORT" validate:"min=1,max=65535"`
+	Mode string `env:"MODE" validate:"oneof=dev staging prod"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	// Test case 1: All rules pass
+	t.Run("All rules pass", func(t *testing.T) {
+		cfg := validateConfig{Host: "localhost", Port: 8080, Mode: "prod"}
+		if err := feng.ValidateStruct(&cfg); err != nil {
+			t.Errorf("ValidateStruct returned an error: %v", err)
+		}
+	})
+
+	// Test case 2: Each rule failing on its own
+	t.Run("Each rule failing", func(t *testing.T) {
+		tests := []struct {
+			name string
+			cfg  validateConfig
+			want string
+		}{
+			{"required", validateConfig{Port: 80, Mode: "dev"}, "HOST: is required"},
+			{"min", validateConfig{Host: "h", Port: 0, Mode: "dev"}, "PORT: must be at least 1"},
+			{"max", validateConfig{Host: "h", Port: 70000, Mode: "dev"}, "PORT: must be at most 65535"},
+			{"oneof", validateConfig{Host: "h", Port: 80, Mode: "qa"}, "MODE: must be one of"},
+		}
+		for _, tt := range tests {
+			err := feng.ValidateStruct(tt.cfg)
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+				continue
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: expected error containing %q, got %q", tt.name, tt.want, err.Error())
+			}
+		}
+	})
+
+	// Test case 3: Multiple violations are aggregated
+	t.Run("Multiple violations", func(t *testing.T) {
+		err := feng.ValidateStruct(&validateConfig{Port: -1, Mode: "qa"})
+		var verrs feng.ValidationErrors
+		if !errors.As(err, &verrs) {
+			t.Fatalf("Expected ValidationErrors, got %v", err)
+		}
+		if len(verrs) != 3 {
+			t.Errorf("Expected 3 violations, got %d: %v", len(verrs), verrs)
+		}
+	})
+
+	// Test case 4: String length rules
+	t.Run("String length rules", func(t *testing.T) {
+		cfg := struct {
+			Name string `env:"NAME" validate:"min=3,max=5"`
+		}{Name: "ab"}
+		if err := feng.ValidateStruct(&cfg); err == nil {
+			t.Error("Expected an error for a too short string")
+		}
+		cfg.Name = "abcd"
+		if err := feng.ValidateStruct(&cfg); err != nil {
+			t.Errorf("ValidateStruct returned an error: %v", err)
+		}
+	})
+}
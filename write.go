@@ -0,0 +1,174 @@
+package feng
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// writeOptions holds the settings applied by WriteOption values.
+type writeOptions struct {
+	export      bool
+	createEmpty bool
+}
+
+// WriteOption configures how env files are written.
+type WriteOption func(*writeOptions)
+
+// WithExport prefixes every written line with `export ` when enabled, producing a file
+// that can be sourced directly by a shell.
+func WithExport(enabled bool) WriteOption {
+	return func(o *writeOptions) {
+		o.export = enabled
+	}
+}
+
+// WithCreateEmpty controls what WriteEnvFile does when no variable matches its prefix.
+// When enabled an empty file is created, truncating any existing one; by default
+// nothing is written and the file is left untouched.
+func WithCreateEmpty(enabled bool) WriteOption {
+	return func(o *writeOptions) {
+		o.createEmpty = enabled
+	}
+}
+
+// WriteEnvFileMap writes envMap to filename as an env file.
+//
+// Keys are written in sorted order, one `KEY=value` line each; use
+// OrderedMap.WriteEnvFile to keep the order of the source file instead. Values
+// containing whitespace, quotes or a comment character are quoted, and values
+// containing a newline are written in heredoc form, so the file reads back with
+// ReadEnvFile to the same map. Every entry ends with a single newline, so a non-empty
+// file ends with exactly one newline and an empty map yields an empty file.
+func WriteEnvFileMap(filename string, envMap map[string]string, opts ...WriteOption) error {
+	return writeEnvFile(filename, sortedKeys(envMap), envMap, opts)
+}
+
+// WriteEnvTo streams envMap to w in env file format.
+//
+// Lines are written in sorted key order through a small buffer, so large maps are
+// never formatted into memory as a whole. w may be any writer, such as os.Stdout or
+// a gzip.Writer. Entries are formatted as described for WriteEnvFileMap.
+func WriteEnvTo(w io.Writer, envMap map[string]string, opts ...WriteOption) error {
+	return writeEnv(w, sortedKeys(envMap), envMap, opts)
+}
+
+// writeEnvFile creates filename and writes the entries of values to it in the order
+// given by keys.
+func writeEnvFile(filename string, keys []string, values map[string]string, opts []WriteOption) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := writeEnv(f, keys, values, opts); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeEnv writes the entries of values to w in the order given by keys.
+func writeEnv(w io.Writer, keys []string, values map[string]string, opts []WriteOption) error {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, k := range keys {
+		if o.export {
+			if _, err := bw.WriteString("export "); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.WriteString(formatEntry(k, values[k]) + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// quoteValue quotes v if it would not survive a round trip through the parser unquoted.
+//
+// Double quotes are preferred; single quotes are used when v itself contains a double
+// quote.
+func quoteValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t#\"'=") {
+		return v
+	}
+	if strings.Contains(v, `"`) {
+		return "'" + v + "'"
+	}
+	return `"` + v + `"`
+}
+
+// SetInFile sets key to value in the env file filename, leaving everything else intact.
+//
+// If key is already defined, its last definition is replaced in place, keeping an
+// `export ` prefix if present; otherwise a new entry is appended. A heredoc definition
+// is replaced as a whole, and a value containing a newline is written as a heredoc.
+// Comments, ordering and other keys are preserved. A missing file is created.
+func SetInFile(filename, key, value string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	entries, err := ParseWithPositions(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	var last *Entry
+	for i := range entries {
+		if entries[i].Key == key {
+			last = &entries[i]
+		}
+	}
+
+	content := string(data)
+	newEntry := formatEntry(key, value)
+	if last != nil {
+		lines := strings.Split(content, "\n")
+		if strings.HasPrefix(strings.TrimSpace(lines[last.Line-1]), "export ") {
+			newEntry = "export " + newEntry
+		}
+		replaced := append(append(lines[:last.Line-1:last.Line-1], newEntry), lines[last.EndLine:]...)
+		content = strings.Join(replaced, "\n")
+	} else {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += newEntry + "\n"
+	}
+
+	return os.WriteFile(filename, []byte(content), 0600)
+}
+
+// formatEntry renders a single assignment without a trailing newline. Values containing
+// a newline are written as a heredoc, with a delimiter that does not occur in the value.
+func formatEntry(key, value string) string {
+	if !strings.Contains(value, "\n") {
+		return key + "=" + quoteValue(value)
+	}
+
+	delim := "EOF"
+	for i := 1; heredocContains(value, delim); i++ {
+		delim = "EOF_" + strconv.Itoa(i)
+	}
+	return key + "<<" + delim + "\n" + value + "\n" + delim
+}
+
+// heredocContains reports whether a line of value would be read as the heredoc
+// delimiter delim.
+func heredocContains(value, delim string) bool {
+	for _, line := range strings.Split(value, "\n") {
+		if strings.TrimSpace(line) == delim {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package feng_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nosusume/feng"
+)
+
+func TestWriteEnvFileMap(t *testing.T) {
+	envMap := map[string]string{
+		"KEY1": "VALUE1",
+		"KEY2": "two words",
+		"KEY3": `say "hi"`,
+	}
+
+	// Test case 1: Written file reads back to the same map
+	t.Run("Round trip", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), ".env")
+		if err := feng.WriteEnvFileMap(filename, envMap); err != nil {
+			t.Fatalf("WriteEnvFileMap returned an error: %v", err)
+		}
+		got, err := feng.ReadEnvFile(filename)
+		if err != nil {
+			t.Fatalf("ReadEnvFile returned an error: %v", err)
+		}
+		if !compareMap(envMap, got) {
+			t.Errorf("Expected %v, but got %v", envMap, got)
+		}
+	})
+
+	// Test case 2: The export prefix is toggled by WithExport
+	for _, export := range []bool{true, false} {
+		filename := filepath.Join(t.TempDir(), ".env")
+		if err := feng.WriteEnvFileMap(filename, envMap, feng.WithExport(export)); err != nil {
+			t.Fatalf("WriteEnvFileMap returned an error: %v", err)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("Failed to read written file: %v", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(lines) != len(envMap) {
+			t.Fatalf("Expected %d lines, got %d", len(envMap), len(lines))
+		}
+		for _, line := range lines {
+			if strings.HasPrefix(line, "export ") != export {
+				t.Errorf("WithExport(%v): unexpected line %q", export, line)
+			}
+		}
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	original := "# database settings\nDB_HOST=localhost\nexport DB_PORT=5432 # default port\n\n# cache\nCACHE_TTL=60\n"
+
+	// Test case 1: Updating an existing key
+	t.Run("Update existing key", func(t *testing.T) {
+		filename := writeTempEnv(t, original)
+		if err := feng.SetInFile(filename, "DB_PORT", "6543"); err != nil {
+			t.Fatalf("SetInFile returned an error: %v", err)
+		}
+		got, _ := os.ReadFile(filename)
+		want := "# database settings\nDB_HOST=localhost\nexport DB_PORT=6543\n\n# cache\nCACHE_TTL=60\n"
+		if string(got) != want {
+			t.Errorf("Expected %q, but got %q", want, got)
+		}
+	})
+
+	// Test case 2: Adding a new key keeps comments and order
+	t.Run("Add new key", func(t *testing.T) {
+		filename := writeTempEnv(t, original)
+		if err := feng.SetInFile(filename, "DB_USER", "app user"); err != nil {
+			t.Fatalf("SetInFile returned an error: %v", err)
+		}
+		got, _ := os.ReadFile(filename)
+		want := original + "DB_USER=\"app user\"\n"
+		if string(got) != want {
+			t.Errorf("Expected %q, but got %q", want, got)
+		}
+	})
+
+	// Test case 3: Missing file is created
+	t.Run("Missing file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), ".env")
+		if err := feng.SetInFile(filename, "KEY", "VALUE"); err != nil {
+			t.Fatalf("SetInFile returned an error: %v", err)
+		}
+		got, _ := os.ReadFile(filename)
+		if string(got) != "KEY=VALUE\n" {
+			t.Errorf("Unexpected contents: %q", got)
+		}
+	})
+
+	// Test case 4: Updating a heredoc key replaces its whole body
+	t.Run("Update heredoc key", func(t *testing.T) {
+		filename := writeTempEnv(t, "A=1\nCERT<<EOF\nline one\nX=1\nEOF\nB=2\n")
+		if err := feng.SetInFile(filename, "CERT", "new"); err != nil {
+			t.Fatalf("SetInFile returned an error: %v", err)
+		}
+		got, _ := os.ReadFile(filename)
+		if want := "A=1\nCERT=new\nB=2\n"; string(got) != want {
+			t.Errorf("Expected %q, but got %q", want, got)
+		}
+	})
+
+	// Test case 5: A multi-line value is written as a heredoc
+	t.Run("Multi-line value", func(t *testing.T) {
+		filename := writeTempEnv(t, "A=1\nB=2\n")
+		value := "first\nINJECTED=1\nEOF\n"
+		if err := feng.SetInFile(filename, "A", value); err != nil {
+			t.Fatalf("SetInFile returned an error: %v", err)
+		}
+		got, err := feng.ReadEnvFile(filename)
+		if err != nil {
+			t.Fatalf("ReadEnvFile returned an error: %v", err)
+		}
+		if !compareMap(map[string]string{"A": value, "B": "2"}, got) {
+			t.Errorf("Expected the value to round-trip without extra keys, got %q", got)
+		}
+	})
+}
+
+func TestWriteEnvTo(t *testing.T) {
+	envMap := map[string]string{
+		"ZETA":  "last",
+		"ALPHA": "two words",
+		"MID":   "plain",
+	}
+
+	var buf bytes.Buffer
+	if err := feng.WriteEnvTo(&buf, envMap); err != nil {
+		t.Fatalf("WriteEnvTo returned an error: %v", err)
+	}
+	want := "ALPHA=\"two words\"\nMID=plain\nZETA=last\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, but got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := feng.WriteEnvTo(&buf, map[string]string{"KEY": "v"}, feng.WithExport(true)); err != nil {
+		t.Fatalf("WriteEnvTo returned an error: %v", err)
+	}
+	if buf.String() != "export KEY=v\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	// A named map type is written like a plain map
+	type env map[string]string
+	buf.Reset()
+	if err := feng.WriteEnvTo(&buf, env{"B": "2", "A": "1"}); err != nil {
+		t.Fatalf("WriteEnvTo returned an error: %v", err)
+	}
+	if buf.String() != "A=1\nB=2\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	// An ordered map keeps its insertion order
+	m := feng.NewOrderedMap()
+	m.Set("B", "2")
+	m.Set("A", "1")
+	buf.Reset()
+	if err := m.WriteEnvTo(&buf, feng.WithExport(true)); err != nil {
+		t.Fatalf("WriteEnvTo returned an error: %v", err)
+	}
+	if buf.String() != "export B=2\nexport A=1\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+}
+
+func TestWriteEnvFileEmpty(t *testing.T) {
+	dir := t.TempDir()
+
+	// Test case 1: By default an empty map skips the file
+	filename := filepath.Join(dir, "skip.env")
+	if err := feng.WriteEnvFile("FENG_NO_SUCH_PREFIX_", filename); err != nil {
+		t.Fatalf("WriteEnvFile returned an error: %v", err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be created, got %v", err)
+	}
+
+	// Test case 2: WithCreateEmpty creates an empty file
+	filename = filepath.Join(dir, "empty.env")
+	if err := feng.WriteEnvFile("FENG_NO_SUCH_PREFIX_", filename, feng.WithCreateEmpty(true)); err != nil {
+		t.Fatalf("WriteEnvFile returned an error: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Expected the file to exist: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected an empty file, but got %q", data)
+	}
+
+	// Test case 3: A non-empty map ends in exactly one newline
+	t.Setenv("FENG_WE_A", "1")
+	t.Setenv("FENG_WE_B", "two words")
+	filename = filepath.Join(dir, "full.env")
+	if err := feng.WriteEnvFile("FENG_WE_", filename, feng.WithCreateEmpty(true)); err != nil {
+		t.Fatalf("WriteEnvFile returned an error: %v", err)
+	}
+	data, err = os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "FENG_WE_A=1\nFENG_WE_B=\"two words\"\n"; string(data) != want {
+		t.Errorf("Expected %q, but got %q", want, data)
+	}
+}
+
+func TestWriteEnvToMultiline(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"trailing newline", "x\n", "TRAIL<<EOF\nx\n\nEOF\n"},
+		{"embedded newline", "a\nb", "TRAIL<<EOF\na\nb\nEOF\n"},
+		{"delimiter in value", "EOF\nY=1", "TRAIL<<EOF_1\nEOF\nY=1\nEOF_1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := feng.WriteEnvTo(&buf, map[string]string{"TRAIL": tt.value}); err != nil {
+				t.Fatalf("WriteEnvTo returned an error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected %q, but got %q", tt.want, buf.String())
+			}
+
+			// The file reads back to the same map
+			filename := filepath.Join(t.TempDir(), ".env")
+			if err := feng.WriteEnvFileMap(filename, map[string]string{"TRAIL": tt.value, "NEXT": "1"}); err != nil {
+				t.Fatalf("WriteEnvFileMap returned an error: %v", err)
+			}
+			got, err := feng.ReadEnvFile(filename)
+			if err != nil {
+				t.Fatalf("ReadEnvFile returned an error: %v", err)
+			}
+			if !compareMap(map[string]string{"TRAIL": tt.value, "NEXT": "1"}, got) {
+				t.Errorf("Expected the value to round-trip, but got %q", got)
+			}
+		})
+	}
+}